@@ -0,0 +1,198 @@
+// Package api records the exported API of the public papyrus packages and
+// checks it against the baselines in this directory so that incompatible
+// changes are never made by accident.
+//
+// The covered packages are listed in [packages]. Everything under an internal
+// directory and the example programs are not part of the public API and are
+// not covered.
+//
+// To deliberately change the API, regenerate the baselines with "make api" (or
+// "go test ./api -update") and include the resulting diff in the change.
+package api
+
+import (
+	"flag"
+	"fmt"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "regenerate the API baselines")
+
+// module is the import path of the module.
+const module = "github.com/TLBuf/papyrus"
+
+// packages lists the packages, relative to the module, whose API is covered.
+var packages = []string{
+	"pkg/ast",
+	"pkg/astjson",
+	"pkg/lexer",
+	"pkg/metrics",
+	"pkg/parser",
+	"pkg/source",
+	"pkg/token",
+	"pkg/types",
+}
+
+func TestAPI(t *testing.T) {
+	imp := importer.ForCompiler(token.NewFileSet(), "source", nil)
+	for _, path := range packages {
+		t.Run(path, func(t *testing.T) {
+			pkg, err := imp.Import(module + "/" + path)
+			if err != nil {
+				t.Fatalf("failed to load package: %v", err)
+			}
+			got := features(pkg)
+			baseline := filepath.Join("testdata", filepath.Base(path)+".txt")
+			if *update {
+				if err := os.WriteFile(baseline, []byte(strings.Join(got, "\n")+"\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			data, err := os.ReadFile(baseline)
+			if err != nil {
+				t.Fatalf("failed to read baseline, run \"make api\" to create it: %v", err)
+			}
+			want := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+			removed, added := compare(want, got)
+			for _, f := range removed {
+				t.Errorf("incompatible change, removed or changed: %s", f)
+			}
+			for _, f := range added {
+				t.Errorf("API not in baseline, run \"make api\" to record it: %s", f)
+			}
+		})
+	}
+}
+
+// compare returns the features in the baseline that are missing from the
+// current API and the features in the current API that are not in the baseline.
+func compare(baseline, current []string) (removed, added []string) {
+	for _, f := range baseline {
+		if !slices.Contains(current, f) {
+			removed = append(removed, f)
+		}
+	}
+	for _, f := range current {
+		if !slices.Contains(baseline, f) {
+			added = append(added, f)
+		}
+	}
+	return removed, added
+}
+
+// features returns a sorted description of every exported feature of a
+// package, one per line.
+func features(pkg *types.Package) []string {
+	qualifier := func(other *types.Package) string {
+		if other == pkg {
+			return ""
+		}
+		return other.Name()
+	}
+	var lines []string
+	add := func(format string, args ...any) {
+		lines = append(lines, fmt.Sprintf("pkg %s, %s", pkg.Name(), fmt.Sprintf(format, args...)))
+	}
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() {
+			continue
+		}
+		switch obj := obj.(type) {
+		case *types.Const:
+			add("const %s %s = %s", name, types.TypeString(obj.Type(), qualifier), obj.Val().ExactString())
+		case *types.Var:
+			add("var %s %s", name, types.TypeString(obj.Type(), qualifier))
+		case *types.Func:
+			add("func %s%s", name, signature(obj.Type().(*types.Signature), qualifier))
+		case *types.TypeName:
+			typeFeatures(obj, qualifier, add)
+		}
+	}
+	slices.Sort(lines)
+	return lines
+}
+
+func typeFeatures(obj *types.TypeName, qualifier types.Qualifier, add func(string, ...any)) {
+	name := obj.Name()
+	if obj.IsAlias() {
+		add("type %s = %s", name, types.TypeString(obj.Type(), qualifier))
+		return
+	}
+	switch u := obj.Type().Underlying().(type) {
+	case *types.Struct:
+		add("type %s struct", name)
+		for i := range u.NumFields() {
+			if f := u.Field(i); f.Exported() {
+				add("type %s struct, %s %s", name, f.Name(), types.TypeString(f.Type(), qualifier))
+			}
+		}
+	case *types.Interface:
+		// The whole method set is one feature since adding a method to an
+		// interface is an incompatible change.
+		var methods []string
+		for i := range u.NumMethods() {
+			m := u.Method(i)
+			if m.Exported() {
+				methods = append(methods, m.Name()+signature(m.Type().(*types.Signature), qualifier))
+			} else if !slices.Contains(methods, "unexported methods") {
+				methods = append(methods, "unexported methods")
+			}
+		}
+		slices.Sort(methods)
+		add("type %s interface { %s }", name, strings.Join(methods, "; "))
+	default:
+		add("type %s %s", name, types.TypeString(u, qualifier))
+	}
+	for _, recv := range []types.Type{obj.Type(), types.NewPointer(obj.Type())} {
+		methods := types.NewMethodSet(recv)
+		for i := range methods.Len() {
+			m := methods.At(i).Obj()
+			if !m.Exported() {
+				continue
+			}
+			// Only report methods declared on this receiver, not promoted or
+			// already reported for the value receiver.
+			sig := m.Type().(*types.Signature)
+			if !types.Identical(sig.Recv().Type(), recv) {
+				continue
+			}
+			add("method (%s) %s%s", types.TypeString(recv, qualifier), m.Name(), signature(sig, qualifier))
+		}
+	}
+}
+
+// signature returns the parameters and results of a function signature without
+// their names, which are not part of the API.
+func signature(sig *types.Signature, qualifier types.Qualifier) string {
+	unnamed := func(tuple *types.Tuple) *types.Tuple {
+		vars := make([]*types.Var, tuple.Len())
+		for i := range vars {
+			vars[i] = types.NewParam(token.NoPos, nil, "", tuple.At(i).Type())
+		}
+		return types.NewTuple(vars...)
+	}
+	sig = types.NewSignatureType(nil, nil, nil, unnamed(sig.Params()), unnamed(sig.Results()), sig.Variadic())
+	return strings.TrimPrefix(types.TypeString(sig, qualifier), "func")
+}
+
+func TestCompare(t *testing.T) {
+	baseline := []string{"pkg a, func F()", "pkg a, type T struct", "pkg a, type T struct, X int"}
+	current := []string{"pkg a, func F(int)", "pkg a, type T struct", "pkg a, type T struct, X int", "pkg a, type T struct, Y int"}
+	removed, added := compare(baseline, current)
+	if want := []string{"pkg a, func F()"}; !slices.Equal(removed, want) {
+		t.Errorf("removed = %q, want %q", removed, want)
+	}
+	if want := []string{"pkg a, func F(int)", "pkg a, type T struct, Y int"}; !slices.Equal(added, want) {
+		t.Errorf("added = %q, want %q", added, want)
+	}
+}
@@ -0,0 +1,175 @@
+// Command validate is an example program that checks a directory of Papyrus
+// scripts for syntax errors using only the public papyrus APIs.
+//
+// Usage:
+//
+//	validate [-json dir] [-metrics] mod-dir
+//
+// Every .psc file under mod-dir is parsed and each error is printed as
+// "path:line:column: message" with the path relative to mod-dir. With -json,
+// the AST of each script that parsed without errors is written to dir as JSON.
+// With -metrics, a summary of the time spent parsing is printed to standard
+// error.
+//
+// The exit code is 0 if every script is valid, 1 if any script has errors, and
+// 2 if the program could not run.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/TLBuf/papyrus/pkg/ast"
+	"github.com/TLBuf/papyrus/pkg/astjson"
+	"github.com/TLBuf/papyrus/pkg/metrics"
+	"github.com/TLBuf/papyrus/pkg/parser"
+	"github.com/TLBuf/papyrus/pkg/source"
+)
+
+const (
+	exitValid   = 0
+	exitInvalid = 1
+	exitFailure = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	jsonDir := flags.String("json", "", "write the AST of each valid script to this `dir`")
+	showMetrics := flags.Bool("metrics", false, "print parsing metrics to standard error")
+	if err := flags.Parse(args); err != nil {
+		return exitFailure
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(stderr, "usage: validate [-json dir] [-metrics] mod-dir")
+		return exitFailure
+	}
+	root := flags.Arg(0)
+
+	paths, err := scripts(root)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitFailure
+	}
+	var collector *metrics.Collector
+	if *showMetrics {
+		collector = metrics.NewCollector()
+	}
+	p := parser.New(parser.WithLooseComments(true), parser.WithMetrics(collector))
+
+	code := exitValid
+	for _, path := range paths {
+		text, err := os.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitFailure
+		}
+		script, err := p.Parse(source.NewFile(filepath.Join(root, path), text))
+		problems := diagnostics(script, err)
+		for _, d := range problems {
+			fmt.Fprintf(stdout, "%s:%d:%d: %s\n", filepath.ToSlash(path), d.line, d.column, d.message)
+		}
+		if len(problems) > 0 {
+			code = exitInvalid
+			continue
+		}
+		if *jsonDir != "" {
+			if err := writeJSON(*jsonDir, path, script); err != nil {
+				fmt.Fprintln(stderr, err)
+				return exitFailure
+			}
+		}
+	}
+	if collector != nil {
+		fmt.Fprintln(stderr, collector.Aggregate())
+	}
+	return code
+}
+
+// scripts returns the paths of all script files under root, relative to root
+// and in lexical order.
+func scripts(root string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".psc") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, rel)
+		return nil
+	})
+	sort.Strings(paths)
+	return paths, err
+}
+
+type diagnostic struct {
+	line, column int
+	message      string
+}
+
+// diagnostics returns the problems found while parsing a script, both the
+// error returned by the parser and any errors it recovered from.
+func diagnostics(script *ast.Script, err error) []diagnostic {
+	var found []diagnostic
+	if err != nil {
+		var parseErr parser.Error
+		if errors.As(err, &parseErr) {
+			found = append(found, diagnostic{parseErr.Location.Line, parseErr.Location.Column, parseErr.Message})
+		} else {
+			found = append(found, diagnostic{message: err.Error()})
+		}
+	}
+	if script == nil {
+		return found
+	}
+	add := func(node ast.Node) {
+		if e, ok := node.(ast.Error); ok {
+			r := e.Range()
+			found = append(found, diagnostic{r.Line, r.Column, e.ErrorMessage()})
+		}
+	}
+	for _, stmt := range script.Statements {
+		add(stmt)
+		if state, ok := stmt.(*ast.State); ok {
+			for _, invokable := range state.Invokables {
+				add(invokable)
+			}
+		}
+	}
+	return found
+}
+
+// writeJSON writes the JSON encoding of a script to dir using the script's
+// path with a .json extension.
+func writeJSON(dir, path string, script *ast.Script) error {
+	out := filepath.Join(dir, strings.TrimSuffix(path, filepath.Ext(path))+".json")
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if err := astjson.Encode(f, script, astjson.Options{Comments: true, Indent: "  "}); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,110 @@
+package main_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/TLBuf/papyrus/pkg/astjson"
+	"github.com/google/go-cmp/cmp"
+)
+
+// build builds the validate command and returns the path of the binary.
+func build(t *testing.T) string {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), "validate")
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
+	}
+	out, err := exec.Command("go", "build", "-o", bin, ".").CombinedOutput()
+	if err != nil {
+		t.Fatalf("go build failed: %v\n%s", err, out)
+	}
+	return bin
+}
+
+// run runs the validate binary with args and returns its output and exit code.
+func run(t *testing.T, bin string, args ...string) (stdout, stderr string, code int) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	cmd := exec.Command(bin, args...)
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		code = exitErr.ExitCode()
+	case err != nil:
+		t.Fatalf("failed to run %s: %v", bin, err)
+	}
+	return out.String(), errOut.String(), code
+}
+
+func TestRun(t *testing.T) {
+	bin := build(t)
+	out := t.TempDir()
+	stdout, stderr, code := run(t, bin, "-json", out, filepath.Join("testdata", "mod"))
+	if code != 1 {
+		t.Errorf("exit code mismatch, want: %d, got: %d", 1, code)
+	}
+	want := "Broken.psc:3:1: expected Import, Event, State, Function, Property, or Variable, but found IntLiteral\n" +
+		"quests/Bad.psc:2:1: 'Self' is a reserved word and cannot be used as a name\n"
+	if diff := cmp.Diff(want, stdout); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+	if stderr != "" {
+		t.Errorf("unexpected error output: %s", stderr)
+	}
+
+	entries, err := os.ReadDir(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written []string
+	for _, e := range entries {
+		written = append(written, e.Name())
+	}
+	if diff := cmp.Diff([]string{"Good.json"}, written); diff != "" {
+		t.Fatalf("written files mismatch (-want +got):\n%s", diff)
+	}
+	f, err := os.Open(filepath.Join(out, "Good.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	script, err := astjson.Decode(f)
+	if err != nil {
+		t.Fatalf("failed to decode written script: %v", err)
+	}
+	if script.Name.Text != "good" || script.Extends.Text != "quest" || len(script.Statements) != 2 {
+		t.Errorf("decoded script does not match Good.psc: %+v", script)
+	}
+}
+
+func TestRunValid(t *testing.T) {
+	bin := build(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Valid.psc"), []byte("ScriptName Valid\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr, code := run(t, bin, "-metrics", dir)
+	if code != 0 {
+		t.Errorf("exit code mismatch, want: %d, got: %d\n%s", 0, code, stdout)
+	}
+	if !strings.HasPrefix(stderr, "1 files: ") {
+		t.Errorf("metrics output mismatch, got: %q", stderr)
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	bin := build(t)
+	if _, _, code := run(t, bin); code != 2 {
+		t.Errorf("exit code mismatch, want: %d, got: %d", 2, code)
+	}
+}
@@ -0,0 +1,129 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Difference is a structural difference between two ASTs found by [Compare].
+type Difference struct {
+	// Path locates the differing value from the root of both trees using Go
+	// field and index syntax (e.g. "Script.Statements[2].Name.Text").
+	Path string
+	// A describes the value in the first tree.
+	A string
+	// B describes the value in the second tree.
+	B string
+}
+
+// String returns a description of the difference.
+func (d Difference) String() string {
+	return fmt.Sprintf("%s: %s != %s", d.Path, d.A, d.B)
+}
+
+// Equivalent returns true if two ASTs have the same structure and values.
+//
+// Source ranges are ignored, so a tree parsed from a reformatted script is
+// equivalent to the tree parsed from the original.
+func Equivalent(a, b Node) bool {
+	return len(Compare(a, b, 1)) == 0
+}
+
+// Compare returns the differences between two ASTs in depth-first order,
+// ignoring source ranges as [Equivalent] does.
+//
+// At most limit differences are returned; if limit is not positive, all
+// differences are returned.
+func Compare(a, b Node, limit int) []Difference {
+	c := &comparer{limit: limit}
+	va, vb := reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem()
+	c.compare(rootPath(va, vb), va, vb)
+	return c.diffs
+}
+
+type comparer struct {
+	diffs []Difference
+	limit int
+}
+
+func (c *comparer) full() bool {
+	return c.limit > 0 && len(c.diffs) >= c.limit
+}
+
+func (c *comparer) report(path string, a, b reflect.Value) {
+	c.diffs = append(c.diffs, Difference{Path: path, A: describe(a), B: describe(b)})
+}
+
+func (c *comparer) compare(path string, a, b reflect.Value) {
+	if c.full() {
+		return
+	}
+	switch a.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				c.report(path, a, b)
+			}
+			return
+		}
+		if a.Elem().Type() != b.Elem().Type() {
+			c.report(path, a, b)
+			return
+		}
+		c.compare(path, a.Elem(), b.Elem())
+	case reflect.Struct:
+		for i := range a.NumField() {
+			f := a.Type().Field(i)
+			if !f.IsExported() || f.Type == rangeType {
+				continue
+			}
+			c.compare(path+"."+f.Name, a.Field(i), b.Field(i))
+		}
+	case reflect.Slice:
+		for i := range max(a.Len(), b.Len()) {
+			p := path + "[" + strconv.Itoa(i) + "]"
+			switch {
+			case i >= a.Len():
+				c.report(p, reflect.Value{}, b.Index(i))
+			case i >= b.Len():
+				c.report(p, a.Index(i), reflect.Value{})
+			default:
+				c.compare(p, a.Index(i), b.Index(i))
+			}
+			if c.full() {
+				return
+			}
+		}
+	default:
+		if !a.Equal(b) {
+			c.report(path, a, b)
+		}
+	}
+}
+
+// rootPath returns the name of the kind of node at the root of both trees or
+// "Node" if they differ.
+func rootPath(a, b reflect.Value) string {
+	if a.IsNil() || b.IsNil() || a.Elem().Type() != b.Elem().Type() {
+		return "Node"
+	}
+	return nodeName(a.Elem().Type())
+}
+
+// describe returns a short description of a value for a [Difference].
+func describe(v reflect.Value) string {
+	switch {
+	case !v.IsValid():
+		return "<missing>"
+	case (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) && v.IsNil():
+		return "<nil>"
+	case v.Kind() == reflect.Interface:
+		return describe(v.Elem())
+	case v.Kind() == reflect.Pointer || v.Kind() == reflect.Struct:
+		return nodeName(v.Type())
+	case v.Kind() == reflect.String:
+		return strconv.Quote(v.String())
+	}
+	return fmt.Sprint(v.Interface())
+}
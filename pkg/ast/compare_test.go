@@ -0,0 +1,118 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/TLBuf/papyrus/pkg/ast"
+	"github.com/TLBuf/papyrus/pkg/parser"
+	"github.com/TLBuf/papyrus/pkg/source"
+	"github.com/TLBuf/papyrus/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEquivalentIgnoresRanges(t *testing.T) {
+	a := parse(t, "ScriptName Foo Extends Bar\n\nImport Debug\nState Waiting\nEndState\n")
+	b := parse(t, "ScriptName   Foo   Extends Bar\nImport    Debug\n\n\nState Waiting\n\nEndState")
+	if !ast.Equivalent(a, b) {
+		t.Errorf("Equivalent() = false, want true; differences: %v", ast.Compare(a, b, 0))
+	}
+}
+
+func TestEquivalentAllNodes(t *testing.T) {
+	if !ast.Equivalent(allNodes(), allNodes()) {
+		t.Errorf("Equivalent() = false, want true; differences: %v", ast.Compare(allNodes(), allNodes(), 0))
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  ast.Node
+		limit int
+		want  []ast.Difference
+	}{
+		{
+			name: "identifier_text",
+			a:    parse(t, "ScriptName Foo\nState Waiting\nEndState\n"),
+			b:    parse(t, "ScriptName Foo\nState Done\nEndState\n"),
+			want: []ast.Difference{
+				{Path: "Script.Statements[0].Name.Text", A: `"waiting"`, B: `"done"`},
+			},
+		},
+		{
+			name: "missing_statement",
+			a:    parse(t, "ScriptName Foo\nImport Debug\nImport Utility\n"),
+			b:    parse(t, "ScriptName Foo\nImport Debug\n"),
+			want: []ast.Difference{
+				{Path: "Script.Statements[1]", A: "Import", B: "<missing>"},
+			},
+		},
+		{
+			name: "statement_kind",
+			a:    parse(t, "ScriptName Foo\nImport Debug\n"),
+			b:    parse(t, "ScriptName Foo\nState Debug\nEndState\n"),
+			want: []ast.Difference{
+				{Path: "Script.Statements[0]", A: "Import", B: "State"},
+			},
+		},
+		{
+			name: "optional_child",
+			a:    parse(t, "ScriptName Foo Extends Bar\n"),
+			b:    parse(t, "ScriptName Foo\n"),
+			want: []ast.Difference{
+				{Path: "Script.Extends", A: "Identifier", B: "<nil>"},
+			},
+		},
+		{
+			name: "bool_field",
+			a:    parse(t, "ScriptName Foo\nAuto State Waiting\nEndState\n"),
+			b:    parse(t, "ScriptName Foo\nState Waiting\nEndState\n"),
+			want: []ast.Difference{
+				{Path: "Script.Statements[0].IsAuto", A: "true", B: "false"},
+			},
+		},
+		{
+			name: "type",
+			a:    &ast.TypeLiteral{Type: types.Int{}},
+			b:    &ast.TypeLiteral{Type: types.Object{Name: "Actor"}},
+			want: []ast.Difference{
+				{Path: "TypeLiteral.Type", A: "Int", B: "Object"},
+			},
+		},
+		{
+			name: "different_roots",
+			a:    &ast.Identifier{Text: "Foo"},
+			b:    &ast.StringLiteral{Value: "Foo"},
+			want: []ast.Difference{
+				{Path: "Node", A: "Identifier", B: "StringLiteral"},
+			},
+		},
+		{
+			name:  "limit",
+			a:     parse(t, "ScriptName Foo Extends Bar\nImport Debug\n"),
+			b:     parse(t, "ScriptName Baz Extends Qux\nImport Utility\n"),
+			limit: 2,
+			want: []ast.Difference{
+				{Path: "Script.Name.Text", A: `"foo"`, B: `"baz"`},
+				{Path: "Script.Extends.Text", A: `"bar"`, B: `"qux"`},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ast.Compare(test.a, test.b, test.limit)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Compare() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func parse(t *testing.T, text string) *ast.Script {
+	t.Helper()
+	script, err := parser.New().Parse(source.NewFile("test.psc", []byte(text)))
+	if err != nil {
+		t.Fatalf("Parse() returned an unexpected error: %v", err)
+	}
+	return script
+}
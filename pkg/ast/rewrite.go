@@ -0,0 +1,377 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// Cursor describes a node encountered by [Rewrite] and where it is held in its
+// parent, and provides methods to change the tree at that position.
+type Cursor struct {
+	node   Node
+	parent Node
+	name   string
+	index  int
+
+	// replace stores a node in the position of the cursor.
+	replace func(Node)
+	// list is the list that holds the node, nil if the node is not in a list.
+	list editor
+	// deleted is true if the node has been deleted from its list.
+	deleted bool
+	// inserted is the number of nodes inserted after the node.
+	inserted int
+}
+
+// editor edits the list that holds the node of a [Cursor].
+type editor interface {
+	delete(i int)
+	insert(i int, n Node, name string)
+}
+
+// Node returns the current node, which reflects any replacement.
+func (c *Cursor) Node() Node {
+	return c.node
+}
+
+// Parent returns the parent of the current node or nil for the root.
+func (c *Cursor) Parent() Node {
+	return c.parent
+}
+
+// Name returns the name of the field of the parent that holds the current node
+// (e.g. "Statements") or "" for the root.
+func (c *Cursor) Name() string {
+	return c.name
+}
+
+// Index returns the index of the current node in the list held by the parent
+// field or -1 if the field holds a single node.
+func (c *Cursor) Index() int {
+	return c.index
+}
+
+// Replace replaces the current node with n; the children of n are rewritten
+// in place of those of the current node.
+//
+// If n is nil, the field that holds the current node is cleared (e.g. to
+// remove the default value of a parameter) and there are no children to
+// rewrite. Use [Cursor.Delete] to remove a node from a list instead.
+//
+// Replace panics if n cannot be held by the parent field (e.g. replacing a
+// function statement with a script statement) or if n is nil and the current
+// node is in a list.
+func (c *Cursor) Replace(n Node) {
+	if c.deleted {
+		panic("ast: Replace called after Delete")
+	}
+	if n == nil && c.list != nil {
+		panic(fmt.Sprintf("ast: Replace called with nil for %s, which is a list", c.name))
+	}
+	c.replace(n)
+	c.node = n
+}
+
+// Delete removes the current node from the list that holds it.
+//
+// Delete panics if the current node is not in a list.
+func (c *Cursor) Delete() {
+	if c.list == nil {
+		panic(fmt.Sprintf("ast: Delete called for %s, which is not in a list", c.name))
+	}
+	if c.deleted {
+		panic("ast: Delete called twice")
+	}
+	c.list.delete(c.index)
+	c.deleted = true
+}
+
+// InsertBefore inserts n before the current node in the list that holds it;
+// n is not rewritten.
+//
+// InsertBefore panics if the current node is not in a list or if n cannot be
+// held by the list.
+func (c *Cursor) InsertBefore(n Node) {
+	if c.list == nil {
+		panic(fmt.Sprintf("ast: InsertBefore called for %s, which is not in a list", c.name))
+	}
+	c.list.insert(c.index, n, c.name)
+	c.index++
+}
+
+// InsertAfter inserts n after the current node in the list that holds it; n
+// is not rewritten.
+//
+// InsertAfter panics if the current node is not in a list or if n cannot be
+// held by the list.
+func (c *Cursor) InsertAfter(n Node) {
+	if c.list == nil {
+		panic(fmt.Sprintf("ast: InsertAfter called for %s, which is not in a list", c.name))
+	}
+	i := c.index + 1
+	if c.deleted {
+		i = c.index
+	}
+	c.list.insert(i+c.inserted, n, c.name)
+	c.inserted++
+}
+
+// Rewrite traverses an AST in the same order as [Walk], calling fn for each
+// node with a [Cursor] that can replace or delete the node or insert nodes
+// around it. The children of a node are rewritten only if fn returns true.
+//
+// Nodes inserted by fn are not visited. Rewrite returns the root of the
+// rewritten tree, which differs from node if fn replaced it.
+func Rewrite(node Node, fn func(*Cursor) bool) Node {
+	if node == nil {
+		return nil
+	}
+	r := rewriter(fn)
+	root := node
+	r.apply(&Cursor{
+		node:    node,
+		index:   -1,
+		replace: func(n Node) { root = n },
+	})
+	return root
+}
+
+type rewriter func(*Cursor) bool
+
+func (r rewriter) apply(c *Cursor) {
+	if r(c) && !c.deleted {
+		r.children(c.node)
+	}
+}
+
+func (r rewriter) children(node Node) {
+	switch n := node.(type) {
+	case *Script:
+		rewriteList(r, n, "HeaderComments", &n.HeaderComments)
+		rewriteField(r, n, "Name", &n.Name)
+		rewriteField(r, n, "Extends", &n.Extends)
+		rewriteField(r, n, "Comment", &n.Comment)
+		rewriteList(r, n, "Statements", &n.Statements)
+	case *Import:
+		rewriteField(r, n, "Name", &n.Name)
+	case *State:
+		rewriteField(r, n, "Name", &n.Name)
+		rewriteList(r, n, "Invokables", &n.Invokables)
+	case *Event:
+		rewriteField(r, n, "Name", &n.Name)
+		rewriteList(r, n, "Parameters", &n.Parameters)
+		rewriteField(r, n, "Comment", &n.Comment)
+		rewriteList(r, n, "Statements", &n.Statements)
+	case *Function:
+		rewriteField(r, n, "ReturnType", &n.ReturnType)
+		rewriteField(r, n, "Name", &n.Name)
+		rewriteList(r, n, "Parameters", &n.Parameters)
+		rewriteField(r, n, "Comment", &n.Comment)
+		rewriteList(r, n, "Statements", &n.Statements)
+	case *Property:
+		rewriteField(r, n, "Type", &n.Type)
+		rewriteField(r, n, "Name", &n.Name)
+		rewriteValues(r, n, "Parameters", &n.Parameters)
+		rewriteField(r, n, "Value", &n.Value)
+		rewriteField(r, n, "Comment", &n.Comment)
+		rewriteField(r, n, "Get", &n.Get)
+		rewriteField(r, n, "Set", &n.Set)
+	case *ScriptVariable:
+		rewriteField(r, n, "Type", &n.Type)
+		rewriteField(r, n, "Name", &n.Name)
+		rewriteField(r, n, "Value", &n.Value)
+	case *FunctionVariable:
+		rewriteField(r, n, "Type", &n.Type)
+		rewriteField(r, n, "Name", &n.Name)
+		rewriteField(r, n, "Value", &n.Value)
+	case *Parameter:
+		rewriteField(r, n, "Type", &n.Type)
+		rewriteField(r, n, "Name", &n.Name)
+		rewriteIndirect(r, n, "Value", &n.Value)
+	case *Assignment:
+		rewriteField(r, n, "Assignee", &n.Assignee)
+		rewriteField(r, n, "Operator", &n.Operator)
+		rewriteField(r, n, "Value", &n.Value)
+	case *Return:
+		rewriteField(r, n, "Value", &n.Value)
+	case *If:
+		rewriteField(r, n, "Condition", &n.Condition)
+		rewriteList(r, n, "Consequence", &n.Consequence)
+		rewriteList(r, n, "Alternative", &n.Alternative)
+	case *While:
+		rewriteField(r, n, "Condition", &n.Condition)
+		rewriteList(r, n, "Statements", &n.Statements)
+	case *Access:
+		rewriteField(r, n, "Value", &n.Value)
+		rewriteField(r, n, "Operator", &n.Operator)
+		rewriteField(r, n, "Name", &n.Name)
+	case *Argument:
+		rewriteField(r, n, "Name", &n.Name)
+		rewriteField(r, n, "Operator", &n.Operator)
+		rewriteField(r, n, "Value", &n.Value)
+	case *ArrayCreation:
+		rewriteField(r, n, "NewOperator", &n.NewOperator)
+		rewriteField(r, n, "Type", &n.Type)
+		rewriteField(r, n, "OpenOperator", &n.OpenOperator)
+		rewriteField(r, n, "Size", &n.Size)
+		rewriteField(r, n, "CloseOperator", &n.CloseOperator)
+	case *Binary:
+		rewriteField(r, n, "LeftOperand", &n.LeftOperand)
+		rewriteField(r, n, "Operator", &n.Operator)
+		rewriteField(r, n, "RightOperand", &n.RightOperand)
+	case *Call:
+		rewriteIndirect(r, n, "Function", &n.Function)
+		rewriteList(r, n, "Arguments", &n.Arguments)
+	case *Cast:
+		rewriteField(r, n, "Value", &n.Value)
+		rewriteField(r, n, "Operator", &n.Operator)
+		rewriteField(r, n, "Type", &n.Type)
+	case *Index:
+		rewriteField(r, n, "Value", &n.Value)
+		rewriteField(r, n, "OpenOperator", &n.OpenOperator)
+		rewriteField(r, n, "Index", &n.Index)
+		rewriteField(r, n, "CloseOperator", &n.CloseOperator)
+	case *Length:
+		rewriteField(r, n, "Value", &n.Value)
+		rewriteField(r, n, "AccessOperator", &n.AccessOperator)
+	case *Parenthetical:
+		rewriteField(r, n, "Value", &n.Value)
+	case *Unary:
+		rewriteField(r, n, "Operator", &n.Operator)
+		rewriteField(r, n, "Operand", &n.Operand)
+	}
+}
+
+// rewriteField rewrites the node held by a field, which may be absent.
+func rewriteField[T interface {
+	comparable
+	Node
+}](r rewriter, parent Node, name string, field *T) {
+	var zero T
+	if *field == zero {
+		return
+	}
+	r.apply(&Cursor{
+		node:   *field,
+		parent: parent,
+		name:   name,
+		index:  -1,
+		replace: func(n Node) {
+			if n == nil {
+				*field = zero
+				return
+			}
+			*field = convert[T](n, name)
+		},
+	})
+}
+
+// rewriteIndirect rewrites the node held by a field that points to an
+// interface (e.g. the default value of a parameter), which may be absent or
+// point to a nil interface, as [Walk] allows.
+func rewriteIndirect[T Node](r rewriter, parent Node, name string, field **T) {
+	if *field == nil {
+		return
+	}
+	var node Node = **field
+	if node == nil {
+		return
+	}
+	r.apply(&Cursor{
+		node:   node,
+		parent: parent,
+		name:   name,
+		index:  -1,
+		replace: func(n Node) {
+			if n == nil {
+				*field = nil
+				return
+			}
+			t := convert[T](n, name)
+			*field = &t
+		},
+	})
+}
+
+// rewriteList rewrites each node in a list field.
+func rewriteList[T Node](r rewriter, parent Node, name string, list *[]T) {
+	l := nodeList[T]{list}
+	for i := 0; i < len(*list); {
+		c := &Cursor{
+			node:   (*list)[i],
+			parent: parent,
+			name:   name,
+			index:  i,
+			list:   l,
+		}
+		c.replace = func(n Node) { (*list)[c.index] = convert[T](n, name) }
+		r.apply(c)
+		i = c.index + c.inserted
+		if !c.deleted {
+			i++
+		}
+	}
+}
+
+type nodeList[T Node] struct {
+	list *[]T
+}
+
+func (l nodeList[T]) delete(i int) {
+	*l.list = slices.Delete(*l.list, i, i+1)
+}
+
+func (l nodeList[T]) insert(i int, n Node, name string) {
+	*l.list = slices.Insert(*l.list, i, convert[T](n, name))
+}
+
+// rewriteValues rewrites each node in a list field that holds node values
+// rather than pointers (e.g. the parameters of a property).
+//
+// Nodes are rewritten in place, so the list is only reassigned if nodes are
+// replaced, deleted, or inserted.
+func rewriteValues[T any, P interface {
+	*T
+	Node
+}](r rewriter, parent Node, name string, list *[]T) {
+	nodes := make([]P, len(*list))
+	for i := range *list {
+		nodes[i] = &(*list)[i]
+	}
+	rewriteList(r, parent, name, &nodes)
+	if !valuesChanged(*list, nodes) {
+		return
+	}
+	values := make([]T, len(nodes))
+	for i, n := range nodes {
+		values[i] = *n
+	}
+	*list = values
+}
+
+// valuesChanged returns true if nodes no longer points to each value in list.
+func valuesChanged[T any, P interface {
+	*T
+	Node
+}](list []T, nodes []P) bool {
+	if len(nodes) != len(list) {
+		return true
+	}
+	for i, n := range nodes {
+		if n != &list[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// convert returns n as the type held by the named field or panics if it cannot
+// be held by the field.
+func convert[T Node](n Node, name string) T {
+	t, ok := n.(T)
+	if !ok {
+		panic(fmt.Sprintf("ast: cannot use %T as %s in %s", n, reflect.TypeFor[T](), name))
+	}
+	return t
+}
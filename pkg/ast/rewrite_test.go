@@ -0,0 +1,391 @@
+package ast_test
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/TLBuf/papyrus/pkg/ast"
+	"github.com/TLBuf/papyrus/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRewriteVisitsSameNodesAsWalk(t *testing.T) {
+	var want []ast.Node
+	ast.Inspect(allNodes(), func(n ast.Node) bool {
+		want = append(want, n)
+		return true
+	})
+	var got []ast.Node
+	ast.Rewrite(allNodes(), func(c *ast.Cursor) bool {
+		got = append(got, c.Node())
+		return true
+	})
+	if len(got) != len(want) {
+		t.Fatalf("Rewrite() visited %d nodes, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !ast.Equivalent(got[i], want[i]) {
+			t.Errorf("node %d = %T, want %T", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	trace := func(arg string) *ast.Call {
+		var callee ast.Reference = &ast.Access{
+			Value:    &ast.Identifier{Text: "debug"},
+			Operator: &ast.AccessOperator{},
+			Name:     &ast.Identifier{Text: "trace"},
+		}
+		return &ast.Call{Function: &callee, Arguments: []*ast.Argument{{Value: &ast.Identifier{Text: arg}}}}
+	}
+	log := func(arg string) *ast.Call {
+		var callee ast.Reference = &ast.Identifier{Text: "log"}
+		return &ast.Call{Function: &callee, Arguments: []*ast.Argument{{Value: &ast.Identifier{Text: arg}}}}
+	}
+	isTrace := func(n ast.Node) bool {
+		call, ok := n.(*ast.Call)
+		if !ok || call.Function == nil {
+			return false
+		}
+		access, ok := (*call.Function).(*ast.Access)
+		if !ok {
+			return false
+		}
+		value, ok := access.Value.(*ast.Identifier)
+		return ok && value.Text == "debug" && access.Name.Text == "trace"
+	}
+	script := func(statements ...ast.FunctionStatement) *ast.Script {
+		return &ast.Script{
+			Name: &ast.Identifier{Text: "foo"},
+			Statements: []ast.ScriptStatement{
+				&ast.Function{Name: &ast.Identifier{Text: "f"}, Statements: statements},
+			},
+		}
+	}
+	tests := []struct {
+		name string
+		node ast.Node
+		fn   func(*ast.Cursor) bool
+		want ast.Node
+	}{
+		{
+			name: "replace_calls",
+			node: script(
+				&ast.Return{Value: trace("a")},
+				&ast.If{
+					Condition:   &ast.BoolLiteral{Value: true},
+					Consequence: []ast.FunctionStatement{&ast.Return{Value: trace("b")}},
+				},
+			),
+			fn: func(c *ast.Cursor) bool {
+				if isTrace(c.Node()) {
+					c.Replace(log(c.Node().(*ast.Call).Arguments[0].Value.(*ast.Identifier).Text))
+				}
+				return true
+			},
+			want: script(
+				&ast.Return{Value: log("a")},
+				&ast.If{
+					Condition:   &ast.BoolLiteral{Value: true},
+					Consequence: []ast.FunctionStatement{&ast.Return{Value: log("b")}},
+				},
+			),
+		},
+		{
+			name: "delete_from_if",
+			node: script(&ast.If{
+				Condition: &ast.BoolLiteral{Value: true},
+				Consequence: []ast.FunctionStatement{
+					&ast.Return{Value: &ast.IntLiteral{Value: 1}},
+					&ast.Return{Value: trace("a")},
+					&ast.Return{Value: &ast.IntLiteral{Value: 2}},
+				},
+			}),
+			fn: func(c *ast.Cursor) bool {
+				if r, ok := c.Node().(*ast.Return); ok && isTrace(r.Value) {
+					c.Delete()
+				}
+				return true
+			},
+			want: script(&ast.If{
+				Condition: &ast.BoolLiteral{Value: true},
+				Consequence: []ast.FunctionStatement{
+					&ast.Return{Value: &ast.IntLiteral{Value: 1}},
+					&ast.Return{Value: &ast.IntLiteral{Value: 2}},
+				},
+			}),
+		},
+		{
+			name: "insert",
+			node: script(&ast.Return{Value: &ast.IntLiteral{Value: 2}}),
+			fn: func(c *ast.Cursor) bool {
+				if c.Name() == "Statements" && c.Index() >= 0 {
+					if _, ok := c.Parent().(*ast.Function); ok {
+						c.InsertAfter(&ast.Return{Value: &ast.IntLiteral{Value: 4}})
+						c.InsertBefore(&ast.Return{Value: &ast.IntLiteral{Value: 1}})
+						c.InsertAfter(&ast.Return{Value: &ast.IntLiteral{Value: 5}})
+						c.InsertBefore(&ast.Return{Value: trace("a")})
+					}
+				}
+				return true
+			},
+			want: script(
+				&ast.Return{Value: &ast.IntLiteral{Value: 1}},
+				&ast.Return{Value: trace("a")},
+				&ast.Return{Value: &ast.IntLiteral{Value: 2}},
+				&ast.Return{Value: &ast.IntLiteral{Value: 4}},
+				&ast.Return{Value: &ast.IntLiteral{Value: 5}},
+			),
+		},
+		{
+			name: "delete_and_insert",
+			node: script(
+				&ast.Return{Value: &ast.IntLiteral{Value: 1}},
+				&ast.Return{Value: &ast.IntLiteral{Value: 2}},
+				&ast.Return{Value: &ast.IntLiteral{Value: 3}},
+			),
+			fn: func(c *ast.Cursor) bool {
+				if r, ok := c.Node().(*ast.Return); ok && r.Value.(*ast.IntLiteral).Value == 2 {
+					c.Delete()
+					c.InsertAfter(&ast.Return{Value: &ast.IntLiteral{Value: 22}})
+					c.InsertBefore(&ast.Return{Value: &ast.IntLiteral{Value: 21}})
+				}
+				return true
+			},
+			want: script(
+				&ast.Return{Value: &ast.IntLiteral{Value: 1}},
+				&ast.Return{Value: &ast.IntLiteral{Value: 21}},
+				&ast.Return{Value: &ast.IntLiteral{Value: 22}},
+				&ast.Return{Value: &ast.IntLiteral{Value: 3}},
+			),
+		},
+		{
+			name: "property_parameters",
+			node: &ast.Property{
+				Name: &ast.Identifier{Text: "p"},
+				Parameters: []ast.Parameter{
+					{Type: &ast.TypeLiteral{Type: types.Int{}}, Name: &ast.Identifier{Text: "a"}},
+					{Type: &ast.TypeLiteral{Type: types.Int{}}, Name: &ast.Identifier{Text: "b"}},
+				},
+			},
+			fn: func(c *ast.Cursor) bool {
+				if p, ok := c.Node().(*ast.Parameter); ok && p.Name.Text == "a" {
+					c.Delete()
+				}
+				if id, ok := c.Node().(*ast.Identifier); ok && id.Text == "b" {
+					c.Replace(&ast.Identifier{Text: "c"})
+				}
+				return true
+			},
+			want: &ast.Property{
+				Name: &ast.Identifier{Text: "p"},
+				Parameters: []ast.Parameter{
+					{Type: &ast.TypeLiteral{Type: types.Int{}}, Name: &ast.Identifier{Text: "c"}},
+				},
+			},
+		},
+		{
+			name: "prune",
+			node: script(&ast.Return{Value: trace("a")}),
+			fn: func(c *ast.Cursor) bool {
+				if isTrace(c.Node()) {
+					c.Replace(log("b"))
+				}
+				_, ok := c.Node().(*ast.Script)
+				return ok
+			},
+			want: script(&ast.Return{Value: trace("a")}),
+		},
+		{
+			name: "clear_field",
+			node: script(&ast.Return{Value: &ast.IntLiteral{Value: 1}}),
+			fn: func(c *ast.Cursor) bool {
+				if _, ok := c.Node().(*ast.IntLiteral); ok {
+					c.Replace(nil)
+				}
+				return true
+			},
+			want: script(&ast.Return{}),
+		},
+		{
+			name: "clear_parameter_value",
+			node: &ast.Parameter{
+				Type:  &ast.TypeLiteral{Type: types.Int{}},
+				Name:  &ast.Identifier{Text: "a"},
+				Value: func() *ast.Literal { var v ast.Literal = &ast.IntLiteral{Value: 1}; return &v }(),
+			},
+			fn: func(c *ast.Cursor) bool {
+				if c.Name() == "Value" {
+					c.Replace(nil)
+				}
+				return true
+			},
+			want: &ast.Parameter{
+				Type: &ast.TypeLiteral{Type: types.Int{}},
+				Name: &ast.Identifier{Text: "a"},
+			},
+		},
+		{
+			name: "replace_root",
+			node: &ast.Identifier{Text: "a"},
+			fn: func(c *ast.Cursor) bool {
+				c.Replace(&ast.Identifier{Text: "b"})
+				return true
+			},
+			want: &ast.Identifier{Text: "b"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ast.Rewrite(test.node, test.fn)
+			if diffs := ast.Compare(test.want, got, 0); len(diffs) > 0 {
+				t.Errorf("Rewrite() differs from the expected tree: %v", diffs)
+			}
+		})
+	}
+}
+
+func TestRewriteCursor(t *testing.T) {
+	var got []string
+	ast.Rewrite(&ast.Import{Name: &ast.Identifier{Text: "a"}}, func(c *ast.Cursor) bool {
+		got = append(got, fmt.Sprintf("%T.%s[%d]", c.Parent(), c.Name(), c.Index()))
+		return true
+	})
+	want := []string{"<nil>.[-1]", "*ast.Import.Name[-1]"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("cursor positions mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRewritePanics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(*ast.Cursor)
+	}{
+		{
+			name: "delete_field",
+			fn:   func(c *ast.Cursor) { c.Delete() },
+		},
+		{
+			name: "insert_field",
+			fn:   func(c *ast.Cursor) { c.InsertAfter(&ast.Identifier{}) },
+		},
+		{
+			name: "replace_wrong_kind",
+			fn:   func(c *ast.Cursor) { c.Replace(&ast.IntLiteral{}) },
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Rewrite() did not panic")
+				}
+			}()
+			ast.Rewrite(&ast.Import{Name: &ast.Identifier{Text: "a"}}, func(c *ast.Cursor) bool {
+				if c.Name() == "Name" {
+					test.fn(c)
+				}
+				return true
+			})
+		})
+	}
+}
+
+func TestRewriteReplaceNilInListPanics(t *testing.T) {
+	defer func() {
+		want := "ast: Replace called with nil for Statements, which is a list"
+		if got := recover(); got != want {
+			t.Errorf("Rewrite() panicked with %v, want %q", got, want)
+		}
+	}()
+	script := &ast.Script{
+		Name:       &ast.Identifier{Text: "foo"},
+		Statements: []ast.ScriptStatement{&ast.Import{Name: &ast.Identifier{Text: "a"}}},
+	}
+	ast.Rewrite(script, func(c *ast.Cursor) bool {
+		if c.Name() == "Statements" {
+			c.Replace(nil)
+		}
+		return true
+	})
+}
+
+func TestRewriteNoOpLeavesTree(t *testing.T) {
+	tests := []struct {
+		name string
+		node ast.Node
+	}{
+		{
+			name: "nil_parameters",
+			node: &ast.Property{Name: &ast.Identifier{Text: "p"}},
+		},
+		{
+			name: "parameters",
+			node: &ast.Property{
+				Name: &ast.Identifier{Text: "p"},
+				Parameters: []ast.Parameter{
+					{Type: &ast.TypeLiteral{Type: types.Int{}}, Name: &ast.Identifier{Text: "a"}},
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			want := *test.node.(*ast.Property)
+			want.Parameters = slices.Clone(want.Parameters)
+			got := ast.Rewrite(test.node, func(*ast.Cursor) bool { return true })
+			if !reflect.DeepEqual(got, &want) {
+				t.Errorf("Rewrite() = %#v, want %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestRewriteKeepsPropertyParameters(t *testing.T) {
+	property := &ast.Property{
+		Name: &ast.Identifier{Text: "p"},
+		Parameters: []ast.Parameter{
+			{Type: &ast.TypeLiteral{Type: types.Int{}}, Name: &ast.Identifier{Text: "a"}},
+		},
+	}
+	var got *ast.Parameter
+	ast.Rewrite(property, func(c *ast.Cursor) bool {
+		if p, ok := c.Node().(*ast.Parameter); ok {
+			got = p
+		}
+		return true
+	})
+	if want := &property.Parameters[0]; got != want {
+		t.Errorf("Cursor.Node() = %p, want the parameter in the property at %p", got, want)
+	}
+}
+
+func TestRewriteSkipsNilParameterValue(t *testing.T) {
+	var value ast.Literal
+	parameter := &ast.Parameter{
+		Type:  &ast.TypeLiteral{Type: types.Int{}},
+		Name:  &ast.Identifier{Text: "a"},
+		Value: &value,
+	}
+	var walked, rewritten []ast.Node
+	ast.Inspect(parameter, func(n ast.Node) bool {
+		walked = append(walked, n)
+		return true
+	})
+	ast.Rewrite(parameter, func(c *ast.Cursor) bool {
+		rewritten = append(rewritten, c.Node())
+		return true
+	})
+	if len(rewritten) != len(walked) {
+		t.Fatalf("Rewrite() visited %d nodes, want %d", len(rewritten), len(walked))
+	}
+	for i, n := range rewritten {
+		if n == nil {
+			t.Errorf("Rewrite() visited a nil node at %d", i)
+		}
+	}
+}
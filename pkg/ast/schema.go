@@ -0,0 +1,218 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/TLBuf/papyrus/pkg/source"
+	"github.com/TLBuf/papyrus/pkg/types"
+)
+
+// SchemaVersion is the version of the shape of the AST described by [Schema].
+//
+// Any change to the set of node kinds or their fields increments the version.
+const SchemaVersion = 1
+
+// SchemaDescription is a machine-readable description of every node kind in
+// the AST.
+type SchemaDescription struct {
+	// Version is the [SchemaVersion] of the description.
+	Version int `json:"version"`
+	// Nodes describes each node kind in alphabetical order.
+	Nodes []NodeDescription `json:"nodes"`
+}
+
+// NodeDescription describes a single kind of node.
+type NodeDescription struct {
+	// Kind is the name of the node's type (e.g. "Script").
+	Kind string `json:"kind"`
+	// Interfaces are the names of the node interfaces (e.g. "Expression") the
+	// node satisfies in alphabetical order, not including Node itself.
+	Interfaces []string `json:"interfaces,omitempty"`
+	// Fields describes the node's fields in declaration order.
+	Fields []FieldDescription `json:"fields"`
+}
+
+// FieldDescription describes a single field of a node.
+type FieldDescription struct {
+	// Name is the name of the field (e.g. "Statements").
+	Name string `json:"name"`
+	// Kind is the kind of value the field holds.
+	Kind FieldKind `json:"kind"`
+	// Type is the name of the node kind or interface for node and node list
+	// fields or the name of the enumeration type for enumeration fields.
+	Type string `json:"type,omitempty"`
+	// Values are the textual forms of the valid values of an enumeration field.
+	Values []string `json:"values,omitempty"`
+}
+
+// FieldKind is the kind of value held by a node field.
+type FieldKind string
+
+const (
+	// NodeField holds a single child node, which may be absent.
+	NodeField FieldKind = "node"
+	// NodeListField holds an ordered list of child nodes.
+	NodeListField FieldKind = "nodeList"
+	// StringField holds a string.
+	StringField FieldKind = "string"
+	// BoolField holds a boolean.
+	BoolField FieldKind = "bool"
+	// IntField holds an integer.
+	IntField FieldKind = "int"
+	// FloatField holds a floating point number.
+	FloatField FieldKind = "float"
+	// EnumField holds one of a fixed set of values (e.g. an operator kind).
+	EnumField FieldKind = "enum"
+	// TypeField holds a Papyrus type.
+	TypeField FieldKind = "type"
+	// LocationField holds the source range of the node.
+	LocationField FieldKind = "location"
+)
+
+// nodeKinds holds an instance of every node kind.
+var nodeKinds = []Node{
+	&Access{},
+	&AccessOperator{},
+	&Argument{},
+	&ArrayCloseOperator{},
+	&ArrayCreation{},
+	&ArrayOpenOperator{},
+	&AsOperator{},
+	&Assignment{},
+	&AssignmentOperator{},
+	&Binary{},
+	&BinaryOperator{},
+	&BlockComment{},
+	&BoolLiteral{},
+	&Call{},
+	&Cast{},
+	&DocComment{},
+	&ErrorExpression{},
+	&ErrorFunctionStatement{},
+	&ErrorScriptStatement{},
+	&Event{},
+	&FloatLiteral{},
+	&Function{},
+	&FunctionVariable{},
+	&Identifier{},
+	&If{},
+	&Import{},
+	&Index{},
+	&IntLiteral{},
+	&Length{},
+	&LineComment{},
+	&NewOperator{},
+	&NoneLiteral{},
+	&Parameter{},
+	&Parenthetical{},
+	&Property{},
+	&Return{},
+	&Script{},
+	&ScriptVariable{},
+	&State{},
+	&StringLiteral{},
+	&TypeLiteral{},
+	&Unary{},
+	&UnaryOperator{},
+	&While{},
+}
+
+// nodeInterfaces are the interfaces nodes may satisfy in alphabetical order.
+var nodeInterfaces = []reflect.Type{
+	reflect.TypeFor[Error](),
+	reflect.TypeFor[Expression](),
+	reflect.TypeFor[FunctionStatement](),
+	reflect.TypeFor[Invokable](),
+	reflect.TypeFor[Literal](),
+	reflect.TypeFor[LooseComment](),
+	reflect.TypeFor[Reference](),
+	reflect.TypeFor[ScriptStatement](),
+}
+
+var (
+	nodeType     = reflect.TypeFor[Node]()
+	rangeType    = reflect.TypeFor[source.Range]()
+	typeType     = reflect.TypeFor[types.Type]()
+	stringerType = reflect.TypeFor[fmt.Stringer]()
+)
+
+// Schema returns a description of every node kind in the AST.
+func Schema() SchemaDescription {
+	schema := SchemaDescription{Version: SchemaVersion}
+	for _, n := range nodeKinds {
+		t := reflect.TypeOf(n)
+		node := NodeDescription{Kind: t.Elem().Name()}
+		for _, i := range nodeInterfaces {
+			if t.Implements(i) {
+				node.Interfaces = append(node.Interfaces, i.Name())
+			}
+		}
+		for i := range t.Elem().NumField() {
+			f := t.Elem().Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			field := describeField(f.Type)
+			field.Name = f.Name
+			node.Fields = append(node.Fields, field)
+		}
+		schema.Nodes = append(schema.Nodes, node)
+	}
+	return schema
+}
+
+func describeField(t reflect.Type) FieldDescription {
+	switch {
+	case t == rangeType:
+		return FieldDescription{Kind: LocationField}
+	case t == typeType:
+		return FieldDescription{Kind: TypeField}
+	case isNode(t):
+		return FieldDescription{Kind: NodeField, Type: nodeName(t)}
+	case t.Kind() == reflect.Slice && isNode(t.Elem()):
+		return FieldDescription{Kind: NodeListField, Type: nodeName(t.Elem())}
+	case t.Kind() == reflect.Int && t.Implements(stringerType):
+		return FieldDescription{Kind: EnumField, Type: t.Name(), Values: enumValues(t)}
+	case t.Kind() == reflect.String:
+		return FieldDescription{Kind: StringField}
+	case t.Kind() == reflect.Bool:
+		return FieldDescription{Kind: BoolField}
+	case t.Kind() == reflect.Int:
+		return FieldDescription{Kind: IntField}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return FieldDescription{Kind: FloatField}
+	}
+	panic(fmt.Sprintf("ast: no schema for field type %s", t))
+}
+
+// isNode returns true if t holds a node, either directly, as a node struct
+// value, or through a pointer to a node interface.
+func isNode(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Interface {
+		t = t.Elem()
+	}
+	return t.Implements(nodeType) || reflect.PointerTo(t).Implements(nodeType)
+}
+
+func nodeName(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// enumValues returns the textual forms of the valid values of an enumeration,
+// which start at one and are contiguous.
+func enumValues(t reflect.Type) []string {
+	var values []string
+	for i := 1; ; i++ {
+		v := reflect.New(t).Elem()
+		v.SetInt(int64(i))
+		s := v.Interface().(fmt.Stringer).String()
+		if s == "<unknown>" {
+			return values
+		}
+		values = append(values, s)
+	}
+}
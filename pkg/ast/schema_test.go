@@ -0,0 +1,86 @@
+package ast_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/TLBuf/papyrus/pkg/ast"
+	"github.com/google/go-cmp/cmp"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestSchemaCoversEveryNodeKind(t *testing.T) {
+	var got []string
+	for _, n := range ast.Schema().Nodes {
+		got = append(got, n.Kind)
+	}
+	if diff := cmp.Diff(nodeKinds(t), got); diff != "" {
+		t.Errorf("schema node kinds mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSchemaMatchesStructs(t *testing.T) {
+	// Every node kind is reachable from allNodes, so collect their types from it.
+	structs := make(map[string]reflect.Type)
+	ast.Inspect(allNodes(), func(n ast.Node) bool {
+		typ := reflect.TypeOf(n).Elem()
+		structs[typ.Name()] = typ
+		return true
+	})
+	for _, node := range ast.Schema().Nodes {
+		typ, ok := structs[node.Kind]
+		if !ok {
+			t.Errorf("schema describes unknown node kind %s", node.Kind)
+			continue
+		}
+		var want []string
+		for i := range typ.NumField() {
+			if f := typ.Field(i); f.IsExported() {
+				want = append(want, f.Name)
+			}
+		}
+		var got []string
+		for _, f := range node.Fields {
+			got = append(got, f.Name)
+			if (f.Kind == ast.NodeField || f.Kind == ast.NodeListField || f.Kind == ast.EnumField) && f.Type == "" {
+				t.Errorf("%s.%s is a %s field without a type", node.Kind, f.Name, f.Kind)
+			}
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%s fields mismatch (-want +got):\n%s", node.Kind, diff)
+		}
+		if !slices.IsSorted(node.Interfaces) {
+			t.Errorf("%s interfaces are not sorted: %v", node.Kind, node.Interfaces)
+		}
+	}
+}
+
+// TestSchemaGolden locks the schema so that changes to the shape of the AST are
+// deliberate: any change must update the golden file and SchemaVersion.
+func TestSchemaGolden(t *testing.T) {
+	got, err := json.MarshalIndent(ast.Schema(), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+	golden := filepath.Join("testdata", "schema.json")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("schema does not match %s, if the change is intended increment SchemaVersion and run with -update:\n%s", golden, cmp.Diff(string(want), string(got)))
+	}
+}
@@ -10,6 +10,12 @@ type Script struct {
 	// Extends is the name of the script this one extends from or nil if this
 	// script doesn't extend another.
 	Extends *Identifier
+	// HeaderComments are the loose comments that appear before the script
+	// header, in source order.
+	//
+	// These are only populated if the parser was configured to retain loose
+	// comments.
+	HeaderComments []LooseComment
 	// Comment is the documentation comment for this script.
 	Comment *DocComment
 	// IsHidden defines whether this is a hidden script (i.e. it doesn't appear in
@@ -19,7 +25,11 @@ type Script struct {
 	// properties can referenced in conditions).
 	IsConditional bool
 	// Statements is the list of statements that constitute the body of the
-	// script.
+	// script in source order.
+	//
+	// Neither the documentation comment nor loose comments are statements, so
+	// the statements are the same whether or not the parser retains loose
+	// comments.
 	Statements []ScriptStatement
 	// SourceRange is the source range of the node.
 	SourceRange source.Range
@@ -0,0 +1,158 @@
+package ast
+
+// Visitor visits the nodes of an AST with [Walk].
+type Visitor interface {
+	// Enter is called for each node before any of its children are visited and
+	// returns whether its children should be visited.
+	Enter(node Node) bool
+	// Leave is called for each node after all of its children have been visited
+	// (or would have been had Enter returned true).
+	Leave(node Node)
+}
+
+// Walk traverses an AST in depth-first order, visiting the children of each
+// node in source order. Absent optional children (e.g. the documentation
+// comment of an undocumented script) are skipped.
+func Walk(v Visitor, node Node) {
+	if node == nil {
+		return
+	}
+	if v.Enter(node) {
+		walkChildren(v, node)
+	}
+	v.Leave(node)
+}
+
+// Inspect traverses an AST in depth-first order like [Walk], calling fn for
+// each node and visiting the children of a node only if fn returns true for it.
+func Inspect(node Node, fn func(Node) bool) {
+	Walk(inspector(fn), node)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Enter(node Node) bool {
+	return f(node)
+}
+
+func (inspector) Leave(Node) {}
+
+func walkChildren(v Visitor, node Node) {
+	switch n := node.(type) {
+	case *Script:
+		walkList(v, n.HeaderComments)
+		walkNode(v, n.Name)
+		walkNode(v, n.Extends)
+		walkNode(v, n.Comment)
+		walkList(v, n.Statements)
+	case *Import:
+		walkNode(v, n.Name)
+	case *State:
+		walkNode(v, n.Name)
+		walkList(v, n.Invokables)
+	case *Event:
+		walkNode(v, n.Name)
+		walkList(v, n.Parameters)
+		walkNode(v, n.Comment)
+		walkList(v, n.Statements)
+	case *Function:
+		walkNode(v, n.ReturnType)
+		walkNode(v, n.Name)
+		walkList(v, n.Parameters)
+		walkNode(v, n.Comment)
+		walkList(v, n.Statements)
+	case *Property:
+		walkNode(v, n.Type)
+		walkNode(v, n.Name)
+		for i := range n.Parameters {
+			Walk(v, &n.Parameters[i])
+		}
+		Walk(v, n.Value)
+		walkNode(v, n.Comment)
+		walkNode(v, n.Get)
+		walkNode(v, n.Set)
+	case *ScriptVariable:
+		walkNode(v, n.Type)
+		walkNode(v, n.Name)
+		Walk(v, n.Value)
+	case *FunctionVariable:
+		walkNode(v, n.Type)
+		walkNode(v, n.Name)
+		Walk(v, n.Value)
+	case *Parameter:
+		walkNode(v, n.Type)
+		walkNode(v, n.Name)
+		if n.Value != nil {
+			Walk(v, *n.Value)
+		}
+	case *Assignment:
+		Walk(v, n.Assignee)
+		walkNode(v, n.Operator)
+		Walk(v, n.Value)
+	case *Return:
+		Walk(v, n.Value)
+	case *If:
+		Walk(v, n.Condition)
+		walkList(v, n.Consequence)
+		walkList(v, n.Alternative)
+	case *While:
+		Walk(v, n.Condition)
+		walkList(v, n.Statements)
+	case *Access:
+		Walk(v, n.Value)
+		walkNode(v, n.Operator)
+		walkNode(v, n.Name)
+	case *Argument:
+		walkNode(v, n.Name)
+		walkNode(v, n.Operator)
+		Walk(v, n.Value)
+	case *ArrayCreation:
+		walkNode(v, n.NewOperator)
+		walkNode(v, n.Type)
+		walkNode(v, n.OpenOperator)
+		walkNode(v, n.Size)
+		walkNode(v, n.CloseOperator)
+	case *Binary:
+		Walk(v, n.LeftOperand)
+		walkNode(v, n.Operator)
+		Walk(v, n.RightOperand)
+	case *Call:
+		if n.Function != nil {
+			Walk(v, *n.Function)
+		}
+		walkList(v, n.Arguments)
+	case *Cast:
+		Walk(v, n.Value)
+		walkNode(v, n.Operator)
+		walkNode(v, n.Type)
+	case *Index:
+		Walk(v, n.Value)
+		walkNode(v, n.OpenOperator)
+		Walk(v, n.Index)
+		walkNode(v, n.CloseOperator)
+	case *Length:
+		Walk(v, n.Value)
+		walkNode(v, n.AccessOperator)
+	case *Parenthetical:
+		Walk(v, n.Value)
+	case *Unary:
+		walkNode(v, n.Operator)
+		Walk(v, n.Operand)
+	}
+}
+
+// walkNode walks a node held by a pointer-typed field, which may be nil.
+func walkNode[T any, P interface {
+	*T
+	Node
+}](v Visitor, node P) {
+	if node != nil {
+		Walk(v, node)
+	}
+}
+
+func walkList[T Node](v Visitor, nodes []T) {
+	for _, n := range nodes {
+		Walk(v, n)
+	}
+}
@@ -0,0 +1,332 @@
+package ast_test
+
+import (
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/TLBuf/papyrus/pkg/ast"
+	"github.com/TLBuf/papyrus/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInspectReachesEveryNodeKind(t *testing.T) {
+	var got []string
+	ast.Inspect(allNodes(), func(n ast.Node) bool {
+		name := reflect.TypeOf(n).Elem().Name()
+		if !slices.Contains(got, name) {
+			got = append(got, name)
+		}
+		return true
+	})
+	slices.Sort(got)
+	if diff := cmp.Diff(nodeKinds(t), got); diff != "" {
+		t.Errorf("node kinds reached mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// nodeKinds returns the names of all node types declared in the ast package,
+// found by looking for their Range methods.
+func nodeKinds(t *testing.T) []string {
+	t.Helper()
+	pkgs, err := parser.ParseDir(token.NewFileSet(), ".", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	for _, f := range pkgs["ast"].Files {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*goast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Name.Name != "Range" {
+				continue
+			}
+			if star, ok := fn.Recv.List[0].Type.(*goast.StarExpr); ok {
+				kinds = append(kinds, star.X.(*goast.Ident).Name)
+			}
+		}
+	}
+	slices.Sort(kinds)
+	return kinds
+}
+
+func TestInspectCount(t *testing.T) {
+	calls := 0
+	ast.Inspect(allNodes(), func(n ast.Node) bool {
+		if _, ok := n.(*ast.Call); ok {
+			calls++
+		}
+		return true
+	})
+	if calls != 1 {
+		t.Errorf("found %d calls, want 1", calls)
+	}
+}
+
+func TestInspectPrune(t *testing.T) {
+	var got []string
+	ast.Inspect(allNodes(), func(n ast.Node) bool {
+		if id, ok := n.(*ast.Identifier); ok {
+			got = append(got, id.Text)
+		}
+		// Only descend into the script itself and its states.
+		switch n.(type) {
+		case *ast.Script, *ast.State:
+			return true
+		}
+		return false
+	})
+	want := []string{"foo", "bar", "waiting"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("identifiers mismatch (-want +got):\n%s", diff)
+	}
+}
+
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) Enter(n ast.Node) bool {
+	r.events = append(r.events, "enter "+reflect.TypeOf(n).Elem().Name())
+	_, isIdentifier := n.(*ast.Identifier)
+	return !isIdentifier
+}
+
+func (r *recorder) Leave(n ast.Node) {
+	r.events = append(r.events, "leave "+reflect.TypeOf(n).Elem().Name())
+}
+
+func TestWalk(t *testing.T) {
+	script := &ast.Script{
+		Name: &ast.Identifier{Text: "foo"},
+		Statements: []ast.ScriptStatement{
+			&ast.Import{Name: &ast.Identifier{Text: "bar"}},
+			&ast.State{Name: &ast.Identifier{Text: "baz"}},
+		},
+	}
+	r := &recorder{}
+	ast.Walk(r, script)
+	want := []string{
+		"enter Script",
+		"enter Identifier",
+		"leave Identifier",
+		"enter Import",
+		"enter Identifier",
+		"leave Identifier",
+		"leave Import",
+		"enter State",
+		"enter Identifier",
+		"leave Identifier",
+		"leave State",
+		"leave Script",
+	}
+	if diff := cmp.Diff(want, r.events); diff != "" {
+		t.Errorf("events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWalkEmptyNodes(t *testing.T) {
+	nodes := []ast.Node{
+		&ast.Script{},
+		&ast.Import{},
+		&ast.State{},
+		&ast.Event{},
+		&ast.Function{},
+		&ast.Property{},
+		&ast.ScriptVariable{},
+		&ast.FunctionVariable{},
+		&ast.Parameter{},
+		&ast.Assignment{},
+		&ast.Return{},
+		&ast.If{},
+		&ast.While{},
+		&ast.Access{},
+		&ast.Argument{},
+		&ast.ArrayCreation{},
+		&ast.Binary{},
+		&ast.Call{},
+		&ast.Cast{},
+		&ast.Index{},
+		&ast.Length{},
+		&ast.Parenthetical{},
+		&ast.Unary{},
+		nil,
+	}
+	for _, n := range nodes {
+		count := 0
+		ast.Inspect(n, func(ast.Node) bool {
+			count++
+			return true
+		})
+		want := 1
+		if n == nil {
+			want = 0
+		}
+		if count != want {
+			t.Errorf("Inspect(%T) visited %d nodes, want %d", n, count, want)
+		}
+	}
+}
+
+// allNodes returns a script containing at least one node of every kind.
+func allNodes() *ast.Script {
+	ident := func(text string) *ast.Identifier {
+		return &ast.Identifier{Text: text}
+	}
+	typeLiteral := func(t types.Type) *ast.TypeLiteral {
+		return &ast.TypeLiteral{Type: t}
+	}
+	var defaultValue ast.Literal = &ast.FloatLiteral{Value: 1.5}
+	var callee ast.Reference = &ast.Access{
+		Value:    ident("debug"),
+		Operator: &ast.AccessOperator{},
+		Name:     ident("trace"),
+	}
+	return &ast.Script{
+		Name:    ident("foo"),
+		Extends: ident("bar"),
+		HeaderComments: []ast.LooseComment{
+			&ast.LineComment{Text: ";#requires SKSE"},
+			&ast.BlockComment{Text: ";/ block /;"},
+		},
+		Comment:       &ast.DocComment{Text: "{Script}"},
+		IsHidden:      true,
+		IsConditional: true,
+		Statements: []ast.ScriptStatement{
+			&ast.Import{Name: ident("baz")},
+			&ast.ScriptVariable{
+				Type:          typeLiteral(types.String{}),
+				Name:          ident("s"),
+				Value:         &ast.StringLiteral{Value: "a \"quoted\"\nstring"},
+				IsConditional: true,
+			},
+			&ast.Property{
+				Name:       ident("p"),
+				Type:       typeLiteral(types.Object{Name: "actor"}),
+				Parameters: []ast.Parameter{{Type: typeLiteral(types.Int{}), Name: ident("x")}},
+				IsHidden:   true,
+				Comment:    &ast.DocComment{Text: "{Property}"},
+				Value:      &ast.NoneLiteral{},
+				Get: &ast.Function{
+					Name:       ident("get"),
+					ReturnType: typeLiteral(types.Object{Name: "actor"}),
+				},
+				Set: &ast.Function{
+					Name: ident("set"),
+					Parameters: []*ast.Parameter{
+						{Type: typeLiteral(types.Object{Name: "actor"}), Name: ident("value")},
+					},
+				},
+			},
+			&ast.Property{
+				Name:          ident("q"),
+				Type:          typeLiteral(types.Bool{}),
+				IsConditional: true,
+				IsAuto:        true,
+				IsReadOnly:    true,
+				Value:         &ast.BoolLiteral{Value: true},
+			},
+			&ast.Function{
+				Name:       ident("f"),
+				ReturnType: typeLiteral(types.Array{ElementType: types.Float{}}),
+				Parameters: []*ast.Parameter{
+					{Type: typeLiteral(types.Float{}), Name: ident("a"), Value: &defaultValue},
+				},
+				IsGlobal: true,
+				Comment:  &ast.DocComment{Text: "{Function}"},
+				Statements: []ast.FunctionStatement{
+					&ast.Return{
+						Value: &ast.Parenthetical{
+							Value: &ast.Length{
+								Value: &ast.ArrayCreation{
+									NewOperator:   &ast.NewOperator{},
+									Type:          typeLiteral(types.Float{}),
+									OpenOperator:  &ast.ArrayOpenOperator{},
+									Size:          &ast.IntLiteral{Value: 128},
+									CloseOperator: &ast.ArrayCloseOperator{},
+								},
+								AccessOperator: &ast.AccessOperator{},
+							},
+						},
+					},
+				},
+			},
+			&ast.Function{
+				Name:     ident("n"),
+				IsNative: true,
+			},
+			&ast.State{
+				Name:   ident("waiting"),
+				IsAuto: true,
+				Invokables: []ast.Invokable{
+					&ast.Event{
+						Name: ident("onevent"),
+						Parameters: []*ast.Parameter{
+							{Type: typeLiteral(types.Int{}), Name: ident("i")},
+						},
+						Comment: &ast.DocComment{Text: "{Event}"},
+						Statements: []ast.FunctionStatement{
+							&ast.FunctionVariable{
+								Type: typeLiteral(types.Int{}),
+								Name: ident("x"),
+								Value: &ast.Binary{
+									LeftOperand:  &ast.IntLiteral{Value: -1},
+									Operator:     &ast.BinaryOperator{Kind: ast.Modulo},
+									RightOperand: &ast.ErrorExpression{Message: "bad"},
+								},
+							},
+							&ast.Assignment{
+								Assignee: &ast.Index{
+									Value:         ident("arr"),
+									OpenOperator:  &ast.ArrayOpenOperator{},
+									Index:         &ast.IntLiteral{Value: 0},
+									CloseOperator: &ast.ArrayCloseOperator{},
+								},
+								Operator: &ast.AssignmentOperator{Kind: ast.AssignAdd},
+								Value: &ast.Cast{
+									Value:    ident("y"),
+									Operator: &ast.AsOperator{},
+									Type:     typeLiteral(types.Int{}),
+								},
+							},
+							&ast.If{
+								Condition: &ast.Unary{
+									Operator: &ast.UnaryOperator{Kind: ast.LogicalNot},
+									Operand:  ident("z"),
+								},
+								Consequence: []ast.FunctionStatement{
+									&ast.Return{
+										Value: &ast.Call{
+											Function: &callee,
+											Arguments: []*ast.Argument{
+												{Value: ident("a")},
+												{
+													Name:     ident("b"),
+													Operator: &ast.AssignmentOperator{Kind: ast.Assign},
+													Value:    &ast.FloatLiteral{Value: 0.1},
+												},
+											},
+										},
+									},
+								},
+								Alternative: []ast.FunctionStatement{
+									&ast.While{
+										Condition: &ast.BoolLiteral{Value: false},
+										Statements: []ast.FunctionStatement{
+											&ast.ErrorFunctionStatement{Message: "oops"},
+										},
+									},
+								},
+							},
+							&ast.Return{},
+						},
+					},
+					&ast.ErrorScriptStatement{Message: "invokable"},
+				},
+			},
+			&ast.ErrorScriptStatement{Message: "statement"},
+		},
+	}
+}
@@ -0,0 +1,103 @@
+// Package astjson encodes and decodes Papyrus ASTs as JSON.
+//
+// Every node is encoded as a JSON object with a "kind" member that holds the
+// name of the node's type (e.g. "Script" or "Identifier"), one member per
+// child or attribute using the lower camel case name of the corresponding
+// field, and a "location" member holding the node's source range. Absent
+// children, false flags, and empty lists are omitted.
+//
+// The format is versioned by [Version] and any incompatible change to it
+// increments the version.
+package astjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/TLBuf/papyrus/pkg/ast"
+)
+
+// Version is the version of the JSON format produced by [Encode] and accepted
+// by [Decode].
+//
+// The format mirrors the shape of the AST, so it is versioned in lockstep with
+// [ast.SchemaVersion].
+const Version = ast.SchemaVersion
+
+// Options configures how a script is encoded.
+type Options struct {
+	// Comments directs the encoder to include line and block comments.
+	//
+	// Documentation comments are part of the declarations they document and are
+	// always included.
+	Comments bool
+	// Indent is the string used for each level of indentation or empty to
+	// produce compact output.
+	Indent string
+}
+
+// object is a JSON object that preserves the order of its members.
+type object []member
+
+type member struct {
+	name  string
+	value any
+}
+
+// add returns the object with a new member appended unless value is nil.
+func (o object) add(name string, value any) object {
+	if value == nil {
+		return o
+	}
+	return append(o, member{name, value})
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(m.name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.name, err)
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// document is the top-level JSON value.
+type document struct {
+	Version int             `json:"version"`
+	Script  json.RawMessage `json:"script"`
+}
+
+// encodeDocument writes the top-level JSON value for an encoded script.
+func encodeDocument(w io.Writer, script any, opts Options) error {
+	doc := object{{"version", Version}, {"script", script}}
+	var data []byte
+	var err error
+	if opts.Indent != "" {
+		data, err = json.MarshalIndent(doc, "", opts.Indent)
+	} else {
+		data, err = json.Marshal(doc)
+	}
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,338 @@
+package astjson_test
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TLBuf/papyrus/pkg/ast"
+	"github.com/TLBuf/papyrus/pkg/astjson"
+	"github.com/TLBuf/papyrus/pkg/parser"
+	"github.com/TLBuf/papyrus/pkg/source"
+	"github.com/TLBuf/papyrus/pkg/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+var cmpOpts = []cmp.Option{
+	cmpopts.IgnoreFields(source.Range{}, "File"),
+	cmpopts.EquateEmpty(),
+}
+
+func TestGolden(t *testing.T) {
+	input := filepath.Join("testdata", "script.psc")
+	golden := filepath.Join("testdata", "script.json")
+	text, err := os.ReadFile(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := parser.New().Parse(&source.File{Path: input, Text: text})
+	if err != nil {
+		t.Fatalf("Parse() returned an unexpected error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := astjson.Encode(&buf, script, astjson.Options{Comments: true, Indent: "  "}); err != nil {
+		t.Fatalf("Encode() returned an unexpected error: %v", err)
+	}
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(want), buf.String()); diff != "" {
+		t.Errorf("Encode() mismatch (-want +got):\n%s", diff)
+	}
+	got, err := astjson.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode() returned an unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(script, got, cmpOpts...); diff != "" {
+		t.Errorf("Decode() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	script := allNodes()
+	for _, indent := range []string{"", "\t"} {
+		var buf bytes.Buffer
+		if err := astjson.Encode(&buf, script, astjson.Options{Comments: true, Indent: indent}); err != nil {
+			t.Fatalf("Encode() returned an unexpected error: %v", err)
+		}
+		got, err := astjson.Decode(&buf)
+		if err != nil {
+			t.Fatalf("Decode() returned an unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(script, got, cmpOpts...); diff != "" {
+			t.Errorf("Decode() mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestWithoutComments(t *testing.T) {
+	script := allNodes()
+	var buf bytes.Buffer
+	if err := astjson.Encode(&buf, script, astjson.Options{}); err != nil {
+		t.Fatalf("Encode() returned an unexpected error: %v", err)
+	}
+	for _, kind := range []string{"LineComment", "BlockComment", "headerComments"} {
+		if strings.Contains(buf.String(), kind) {
+			t.Errorf("Encode() output contains %s with Comments disabled", kind)
+		}
+	}
+	got, err := astjson.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode() returned an unexpected error: %v", err)
+	}
+	if len(got.HeaderComments) != 0 {
+		t.Errorf("Decode() returned %d header comments with Comments disabled, want 0", len(got.HeaderComments))
+	}
+	if diff := cmp.Diff(script.Comment, got.Comment, cmpOpts...); diff != "" {
+		t.Errorf("Decode() script comment mismatch with Comments disabled (-want +got):\n%s", diff)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "version",
+			input: `{"version":0,"script":{"kind":"Script"}}`,
+			want:  "unsupported version 0, expected 1",
+		},
+		{
+			name:  "unknown_kind",
+			input: `{"version":1,"script":{"kind":"Struct"}}`,
+			want:  `unknown node kind "Struct"`,
+		},
+		{
+			name:  "not_script",
+			input: `{"version":1,"script":{"kind":"Identifier","text":"foo"}}`,
+			want:  "expected a Script node, but found *ast.Identifier",
+		},
+		{
+			name:  "wrong_child",
+			input: `{"version":1,"script":{"kind":"Script","name":{"kind":"NoneLiteral"}}}`,
+			want:  "name: unexpected node type *ast.NoneLiteral",
+		},
+		{
+			name:  "unknown_operator",
+			input: `{"version":1,"script":{"kind":"Script","statements":[{"kind":"Import","name":{"kind":"BinaryOperator","operator":"^"}}]}}`,
+			want:  `unknown operator "^"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := astjson.Decode(strings.NewReader(test.input))
+			if err == nil {
+				t.Fatalf("Decode() returned no error, want %q", test.want)
+			}
+			if err.Error() != test.want {
+				t.Errorf("Decode() returned error %q, want %q", err.Error(), test.want)
+			}
+		})
+	}
+}
+
+// allNodes returns a script that contains at least one of every kind of node.
+func allNodes() *ast.Script {
+	offset := 0
+	rng := func() source.Range {
+		offset++
+		return source.Range{ByteOffset: offset, Length: 1, Line: 1, Column: offset + 1}
+	}
+	ident := func(text string) *ast.Identifier {
+		return &ast.Identifier{Text: text, SourceRange: rng()}
+	}
+	typeLiteral := func(t types.Type) *ast.TypeLiteral {
+		return &ast.TypeLiteral{Type: t, SourceRange: rng()}
+	}
+	var defaultValue ast.Literal = &ast.FloatLiteral{Value: 1.5, SourceRange: rng()}
+	var callee ast.Reference = &ast.Access{
+		Value:       ident("debug"),
+		Operator:    &ast.AccessOperator{SourceRange: rng()},
+		Name:        ident("trace"),
+		SourceRange: rng(),
+	}
+	return &ast.Script{
+		Name:    ident("foo"),
+		Extends: ident("bar"),
+		HeaderComments: []ast.LooseComment{
+			&ast.LineComment{Text: ";#requires SKSE", SourceRange: rng()},
+			&ast.BlockComment{Text: ";/ block /;", SourceRange: rng()},
+		},
+		Comment:       &ast.DocComment{Text: "{Script}", SourceRange: rng()},
+		IsHidden:      true,
+		IsConditional: true,
+		Statements: []ast.ScriptStatement{
+			&ast.Import{Name: ident("baz"), SourceRange: rng()},
+			&ast.ScriptVariable{
+				Type:          typeLiteral(types.String{}),
+				Name:          ident("s"),
+				Value:         &ast.StringLiteral{Value: "a \"quoted\"\nstring", SourceRange: rng()},
+				IsConditional: true,
+				SourceRange:   rng(),
+			},
+			&ast.Property{
+				Name:       ident("p"),
+				Type:       typeLiteral(types.Object{Name: "actor"}),
+				Parameters: []ast.Parameter{{Type: typeLiteral(types.Int{}), Name: ident("x"), SourceRange: rng()}},
+				IsHidden:   true,
+				Comment:    &ast.DocComment{Text: "{Property}", SourceRange: rng()},
+				Value:      &ast.NoneLiteral{SourceRange: rng()},
+				Get: &ast.Function{
+					Name:        ident("get"),
+					ReturnType:  typeLiteral(types.Object{Name: "actor"}),
+					SourceRange: rng(),
+				},
+				Set: &ast.Function{
+					Name: ident("set"),
+					Parameters: []*ast.Parameter{
+						{Type: typeLiteral(types.Object{Name: "actor"}), Name: ident("value"), SourceRange: rng()},
+					},
+					SourceRange: rng(),
+				},
+				SourceRange: rng(),
+			},
+			&ast.Property{
+				Name:          ident("q"),
+				Type:          typeLiteral(types.Bool{}),
+				IsConditional: true,
+				IsAuto:        true,
+				IsReadOnly:    true,
+				Value:         &ast.BoolLiteral{Value: true, SourceRange: rng()},
+				SourceRange:   rng(),
+			},
+			&ast.Function{
+				Name:       ident("f"),
+				ReturnType: typeLiteral(types.Array{ElementType: types.Float{}}),
+				Parameters: []*ast.Parameter{
+					{Type: typeLiteral(types.Float{}), Name: ident("a"), Value: &defaultValue, SourceRange: rng()},
+				},
+				IsGlobal: true,
+				Comment:  &ast.DocComment{Text: "{Function}", SourceRange: rng()},
+				Statements: []ast.FunctionStatement{
+					&ast.Return{
+						Value: &ast.Parenthetical{
+							Value: &ast.Length{
+								Value: &ast.ArrayCreation{
+									NewOperator:   &ast.NewOperator{SourceRange: rng()},
+									Type:          typeLiteral(types.Float{}),
+									OpenOperator:  &ast.ArrayOpenOperator{SourceRange: rng()},
+									Size:          &ast.IntLiteral{Value: 128, SourceRange: rng()},
+									CloseOperator: &ast.ArrayCloseOperator{SourceRange: rng()},
+									SourceRange:   rng(),
+								},
+								AccessOperator: &ast.AccessOperator{SourceRange: rng()},
+								SourceRange:    rng(),
+							},
+							SourceRange: rng(),
+						},
+						SourceRange: rng(),
+					},
+				},
+				SourceRange: rng(),
+			},
+			&ast.Function{
+				Name:        ident("n"),
+				IsNative:    true,
+				SourceRange: rng(),
+			},
+			&ast.State{
+				Name:   ident("waiting"),
+				IsAuto: true,
+				Invokables: []ast.Invokable{
+					&ast.Event{
+						Name: ident("onevent"),
+						Parameters: []*ast.Parameter{
+							{Type: typeLiteral(types.Int{}), Name: ident("i"), SourceRange: rng()},
+						},
+						Comment: &ast.DocComment{Text: "{Event}", SourceRange: rng()},
+						Statements: []ast.FunctionStatement{
+							&ast.FunctionVariable{
+								Type: typeLiteral(types.Int{}),
+								Name: ident("x"),
+								Value: &ast.Binary{
+									LeftOperand:  &ast.IntLiteral{Value: -1, SourceRange: rng()},
+									Operator:     &ast.BinaryOperator{Kind: ast.Modulo, SourceRange: rng()},
+									RightOperand: &ast.ErrorExpression{Message: "bad", SourceRange: rng()},
+									SourceRange:  rng(),
+								},
+								SourceRange: rng(),
+							},
+							&ast.Assignment{
+								Assignee: &ast.Index{
+									Value:         ident("arr"),
+									OpenOperator:  &ast.ArrayOpenOperator{SourceRange: rng()},
+									Index:         &ast.IntLiteral{Value: 0, SourceRange: rng()},
+									CloseOperator: &ast.ArrayCloseOperator{SourceRange: rng()},
+									SourceRange:   rng(),
+								},
+								Operator: &ast.AssignmentOperator{Kind: ast.AssignAdd, SourceRange: rng()},
+								Value: &ast.Cast{
+									Value:       ident("y"),
+									Operator:    &ast.AsOperator{SourceRange: rng()},
+									Type:        typeLiteral(types.Int{}),
+									SourceRange: rng(),
+								},
+								SourceRange: rng(),
+							},
+							&ast.If{
+								Condition: &ast.Unary{
+									Operator:    &ast.UnaryOperator{Kind: ast.LogicalNot, SourceRange: rng()},
+									Operand:     ident("z"),
+									SourceRange: rng(),
+								},
+								Consequence: []ast.FunctionStatement{
+									&ast.Return{
+										Value: &ast.Call{
+											Function: &callee,
+											Arguments: []*ast.Argument{
+												{Value: ident("a"), SourceRange: rng()},
+												{
+													Name:        ident("b"),
+													Operator:    &ast.AssignmentOperator{Kind: ast.Assign, SourceRange: rng()},
+													Value:       &ast.FloatLiteral{Value: 0.1, SourceRange: rng()},
+													SourceRange: rng(),
+												},
+											},
+											SourceRange: rng(),
+										},
+										SourceRange: rng(),
+									},
+								},
+								Alternative: []ast.FunctionStatement{
+									&ast.While{
+										Condition: &ast.BoolLiteral{Value: false, SourceRange: rng()},
+										Statements: []ast.FunctionStatement{
+											&ast.ErrorFunctionStatement{Message: "oops", SourceRange: rng()},
+										},
+										SourceRange: rng(),
+									},
+								},
+								SourceRange: rng(),
+							},
+							&ast.Return{SourceRange: rng()},
+						},
+						SourceRange: rng(),
+					},
+					&ast.ErrorScriptStatement{Message: "invokable", SourceRange: rng()},
+				},
+				SourceRange: rng(),
+			},
+			&ast.ErrorScriptStatement{Message: "statement", SourceRange: rng()},
+		},
+		SourceRange: rng(),
+	}
+}
@@ -0,0 +1,468 @@
+package astjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/TLBuf/papyrus/pkg/ast"
+	"github.com/TLBuf/papyrus/pkg/source"
+	"github.com/TLBuf/papyrus/pkg/types"
+)
+
+// Decode reads the JSON encoding of a script from r.
+//
+// The source ranges of decoded nodes have no associated [source.File].
+func Decode(r io.Reader) (*ast.Script, error) {
+	var doc document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc.Version != Version {
+		return nil, fmt.Errorf("unsupported version %d, expected %d", doc.Version, Version)
+	}
+	d := &decoder{}
+	node := d.node(doc.Script)
+	if d.err != nil {
+		return nil, d.err
+	}
+	script, ok := node.(*ast.Script)
+	if !ok {
+		return nil, fmt.Errorf("expected a Script node, but found %T", node)
+	}
+	return script, nil
+}
+
+// fields holds the undecoded members of a JSON object.
+type fields map[string]json.RawMessage
+
+type decoder struct {
+	err error
+}
+
+func (d *decoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+func (d *decoder) node(raw json.RawMessage) ast.Node {
+	if d.err != nil || isNull(raw) {
+		return nil
+	}
+	var f fields
+	if err := json.Unmarshal(raw, &f); err != nil {
+		d.fail(err)
+		return nil
+	}
+	kind := str(d, f, "kind")
+	switch kind {
+	case "Script":
+		return &ast.Script{
+			Name:           child[*ast.Identifier](d, f, "name"),
+			Extends:        child[*ast.Identifier](d, f, "extends"),
+			HeaderComments: children[ast.LooseComment](d, f, "headerComments"),
+			Comment:        child[*ast.DocComment](d, f, "comment"),
+			IsHidden:       boolean(d, f, "isHidden"),
+			IsConditional:  boolean(d, f, "isConditional"),
+			Statements:     children[ast.ScriptStatement](d, f, "statements"),
+			SourceRange:    d.location(f),
+		}
+	case "Identifier":
+		return &ast.Identifier{
+			Text:        str(d, f, "text"),
+			SourceRange: d.location(f),
+		}
+	case "DocComment":
+		return &ast.DocComment{
+			Text:        str(d, f, "text"),
+			SourceRange: d.location(f),
+		}
+	case "BlockComment":
+		return &ast.BlockComment{
+			Text:        str(d, f, "text"),
+			SourceRange: d.location(f),
+		}
+	case "LineComment":
+		return &ast.LineComment{
+			Text:        str(d, f, "text"),
+			SourceRange: d.location(f),
+		}
+	case "Import":
+		return &ast.Import{
+			Name:        child[*ast.Identifier](d, f, "name"),
+			SourceRange: d.location(f),
+		}
+	case "State":
+		return &ast.State{
+			Name:        child[*ast.Identifier](d, f, "name"),
+			IsAuto:      boolean(d, f, "isAuto"),
+			Invokables:  children[ast.Invokable](d, f, "invokables"),
+			SourceRange: d.location(f),
+		}
+	case "Event":
+		return &ast.Event{
+			Name:        child[*ast.Identifier](d, f, "name"),
+			Parameters:  children[*ast.Parameter](d, f, "parameters"),
+			IsNative:    boolean(d, f, "isNative"),
+			Comment:     child[*ast.DocComment](d, f, "comment"),
+			Statements:  children[ast.FunctionStatement](d, f, "statements"),
+			SourceRange: d.location(f),
+		}
+	case "Function":
+		return &ast.Function{
+			Name:        child[*ast.Identifier](d, f, "name"),
+			ReturnType:  child[*ast.TypeLiteral](d, f, "returnType"),
+			Parameters:  children[*ast.Parameter](d, f, "parameters"),
+			IsGlobal:    boolean(d, f, "isGlobal"),
+			IsNative:    boolean(d, f, "isNative"),
+			Comment:     child[*ast.DocComment](d, f, "comment"),
+			Statements:  children[ast.FunctionStatement](d, f, "statements"),
+			SourceRange: d.location(f),
+		}
+	case "Property":
+		var params []ast.Parameter
+		for _, p := range children[*ast.Parameter](d, f, "parameters") {
+			params = append(params, *p)
+		}
+		return &ast.Property{
+			Name:          child[*ast.Identifier](d, f, "name"),
+			Type:          child[*ast.TypeLiteral](d, f, "type"),
+			Parameters:    params,
+			IsHidden:      boolean(d, f, "isHidden"),
+			IsConditional: boolean(d, f, "isConditional"),
+			IsAuto:        boolean(d, f, "isAuto"),
+			IsReadOnly:    boolean(d, f, "isReadOnly"),
+			Comment:       child[*ast.DocComment](d, f, "comment"),
+			Value:         child[ast.Literal](d, f, "value"),
+			Get:           child[*ast.Function](d, f, "get"),
+			Set:           child[*ast.Function](d, f, "set"),
+			SourceRange:   d.location(f),
+		}
+	case "ScriptVariable":
+		return &ast.ScriptVariable{
+			Type:          child[*ast.TypeLiteral](d, f, "type"),
+			Name:          child[*ast.Identifier](d, f, "name"),
+			Value:         child[ast.Literal](d, f, "value"),
+			IsConditional: boolean(d, f, "isConditional"),
+			SourceRange:   d.location(f),
+		}
+	case "FunctionVariable":
+		return &ast.FunctionVariable{
+			Type:        child[*ast.TypeLiteral](d, f, "type"),
+			Name:        child[*ast.Identifier](d, f, "name"),
+			Value:       child[ast.Expression](d, f, "value"),
+			SourceRange: d.location(f),
+		}
+	case "Parameter":
+		var value *ast.Literal
+		if v := child[ast.Literal](d, f, "value"); v != nil {
+			value = &v
+		}
+		return &ast.Parameter{
+			Type:        child[*ast.TypeLiteral](d, f, "type"),
+			Name:        child[*ast.Identifier](d, f, "name"),
+			Value:       value,
+			SourceRange: d.location(f),
+		}
+	case "TypeLiteral":
+		return &ast.TypeLiteral{
+			Type:        d.typ(f["type"]),
+			SourceRange: d.location(f),
+		}
+	case "Assignment":
+		return &ast.Assignment{
+			Assignee:    child[ast.Reference](d, f, "assignee"),
+			Operator:    child[*ast.AssignmentOperator](d, f, "operator"),
+			Value:       child[ast.Expression](d, f, "value"),
+			SourceRange: d.location(f),
+		}
+	case "AssignmentOperator":
+		return &ast.AssignmentOperator{
+			Kind:        operator(d, f, ast.AssignmentOperatorKindNames),
+			SourceRange: d.location(f),
+		}
+	case "Return":
+		return &ast.Return{
+			Value:       child[ast.Expression](d, f, "value"),
+			SourceRange: d.location(f),
+		}
+	case "If":
+		return &ast.If{
+			Condition:   child[ast.Expression](d, f, "condition"),
+			Consequence: children[ast.FunctionStatement](d, f, "consequence"),
+			Alternative: children[ast.FunctionStatement](d, f, "alternative"),
+			SourceRange: d.location(f),
+		}
+	case "While":
+		return &ast.While{
+			Condition:   child[ast.Expression](d, f, "condition"),
+			Statements:  children[ast.FunctionStatement](d, f, "statements"),
+			SourceRange: d.location(f),
+		}
+	case "ErrorScriptStatement":
+		return &ast.ErrorScriptStatement{
+			Message:     str(d, f, "message"),
+			SourceRange: d.location(f),
+		}
+	case "ErrorFunctionStatement":
+		return &ast.ErrorFunctionStatement{
+			Message:     str(d, f, "message"),
+			SourceRange: d.location(f),
+		}
+	case "ErrorExpression":
+		return &ast.ErrorExpression{
+			Message:     str(d, f, "message"),
+			SourceRange: d.location(f),
+		}
+	case "Access":
+		return &ast.Access{
+			Value:       child[ast.Expression](d, f, "value"),
+			Operator:    child[*ast.AccessOperator](d, f, "operator"),
+			Name:        child[*ast.Identifier](d, f, "name"),
+			SourceRange: d.location(f),
+		}
+	case "AccessOperator":
+		return &ast.AccessOperator{SourceRange: d.location(f)}
+	case "Argument":
+		return &ast.Argument{
+			Name:        child[*ast.Identifier](d, f, "name"),
+			Operator:    child[*ast.AssignmentOperator](d, f, "operator"),
+			Value:       child[ast.Expression](d, f, "value"),
+			SourceRange: d.location(f),
+		}
+	case "ArrayCreation":
+		return &ast.ArrayCreation{
+			NewOperator:   child[*ast.NewOperator](d, f, "newOperator"),
+			Type:          child[*ast.TypeLiteral](d, f, "type"),
+			OpenOperator:  child[*ast.ArrayOpenOperator](d, f, "openOperator"),
+			Size:          child[*ast.IntLiteral](d, f, "size"),
+			CloseOperator: child[*ast.ArrayCloseOperator](d, f, "closeOperator"),
+			SourceRange:   d.location(f),
+		}
+	case "NewOperator":
+		return &ast.NewOperator{SourceRange: d.location(f)}
+	case "ArrayOpenOperator":
+		return &ast.ArrayOpenOperator{SourceRange: d.location(f)}
+	case "ArrayCloseOperator":
+		return &ast.ArrayCloseOperator{SourceRange: d.location(f)}
+	case "Binary":
+		return &ast.Binary{
+			LeftOperand:  child[ast.Expression](d, f, "leftOperand"),
+			Operator:     child[*ast.BinaryOperator](d, f, "operator"),
+			RightOperand: child[ast.Expression](d, f, "rightOperand"),
+			SourceRange:  d.location(f),
+		}
+	case "BinaryOperator":
+		return &ast.BinaryOperator{
+			Kind:        operator(d, f, ast.BinaryOperatorKindNames),
+			SourceRange: d.location(f),
+		}
+	case "Call":
+		var function *ast.Reference
+		if fn := child[ast.Reference](d, f, "function"); fn != nil {
+			function = &fn
+		}
+		return &ast.Call{
+			Function:    function,
+			Arguments:   children[*ast.Argument](d, f, "arguments"),
+			SourceRange: d.location(f),
+		}
+	case "Cast":
+		return &ast.Cast{
+			Value:       child[ast.Expression](d, f, "value"),
+			Operator:    child[*ast.AsOperator](d, f, "operator"),
+			Type:        child[*ast.TypeLiteral](d, f, "type"),
+			SourceRange: d.location(f),
+		}
+	case "AsOperator":
+		return &ast.AsOperator{SourceRange: d.location(f)}
+	case "Index":
+		return &ast.Index{
+			Value:         child[ast.Expression](d, f, "value"),
+			OpenOperator:  child[*ast.ArrayOpenOperator](d, f, "openOperator"),
+			Index:         child[ast.Expression](d, f, "index"),
+			CloseOperator: child[*ast.ArrayCloseOperator](d, f, "closeOperator"),
+			SourceRange:   d.location(f),
+		}
+	case "Length":
+		return &ast.Length{
+			Value:          child[ast.Expression](d, f, "value"),
+			AccessOperator: child[*ast.AccessOperator](d, f, "accessOperator"),
+			SourceRange:    d.location(f),
+		}
+	case "BoolLiteral":
+		return &ast.BoolLiteral{
+			Value:       boolean(d, f, "value"),
+			SourceRange: d.location(f),
+		}
+	case "IntLiteral":
+		var value int
+		d.value(f, "value", &value)
+		return &ast.IntLiteral{
+			Value:       value,
+			SourceRange: d.location(f),
+		}
+	case "FloatLiteral":
+		var value float32
+		d.value(f, "value", &value)
+		return &ast.FloatLiteral{
+			Value:       value,
+			SourceRange: d.location(f),
+		}
+	case "StringLiteral":
+		return &ast.StringLiteral{
+			Value:       str(d, f, "value"),
+			SourceRange: d.location(f),
+		}
+	case "NoneLiteral":
+		return &ast.NoneLiteral{SourceRange: d.location(f)}
+	case "Parenthetical":
+		return &ast.Parenthetical{
+			Value:       child[ast.Expression](d, f, "value"),
+			SourceRange: d.location(f),
+		}
+	case "Unary":
+		return &ast.Unary{
+			Operator:    child[*ast.UnaryOperator](d, f, "operator"),
+			Operand:     child[ast.Expression](d, f, "operand"),
+			SourceRange: d.location(f),
+		}
+	case "UnaryOperator":
+		return &ast.UnaryOperator{
+			Kind:        operator(d, f, ast.UnaryOperatorKindNames),
+			SourceRange: d.location(f),
+		}
+	}
+	d.fail(fmt.Errorf("unknown node kind %q", kind))
+	return nil
+}
+
+func (d *decoder) typ(raw json.RawMessage) types.Type {
+	if d.err != nil || isNull(raw) {
+		return nil
+	}
+	var f fields
+	if err := json.Unmarshal(raw, &f); err != nil {
+		d.fail(err)
+		return nil
+	}
+	kind := str(d, f, "kind")
+	switch kind {
+	case "Bool":
+		return types.Bool{}
+	case "Int":
+		return types.Int{}
+	case "Float":
+		return types.Float{}
+	case "String":
+		return types.String{}
+	case "Object":
+		return types.Object{Name: str(d, f, "name")}
+	case "Array":
+		elem, ok := d.typ(f["element"]).(types.Scalar)
+		if !ok {
+			d.fail(fmt.Errorf("array element type must be a scalar type"))
+			return nil
+		}
+		return types.Array{ElementType: elem}
+	}
+	d.fail(fmt.Errorf("unknown type kind %q", kind))
+	return nil
+}
+
+func (d *decoder) location(f fields) source.Range {
+	var loc struct {
+		Offset int `json:"offset"`
+		Length int `json:"length"`
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	}
+	d.value(f, "location", &loc)
+	return source.Range{
+		ByteOffset: loc.Offset,
+		Length:     loc.Length,
+		Line:       loc.Line,
+		Column:     loc.Column,
+	}
+}
+
+// value decodes the named member into v if it is present.
+func (d *decoder) value(f fields, name string, v any) {
+	raw, ok := f[name]
+	if d.err != nil || !ok {
+		return
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		d.fail(fmt.Errorf("%s: %w", name, err))
+	}
+}
+
+func str(d *decoder, f fields, name string) string {
+	var s string
+	d.value(f, name, &s)
+	return s
+}
+
+func boolean(d *decoder, f fields, name string) bool {
+	var b bool
+	d.value(f, name, &b)
+	return b
+}
+
+// operator decodes the "operator" member using the given map of operator kinds
+// to their symbols.
+func operator[K comparable](d *decoder, f fields, names map[K]string) K {
+	symbol := str(d, f, "operator")
+	for kind, name := range names {
+		if name == symbol {
+			return kind
+		}
+	}
+	var unknown K
+	d.fail(fmt.Errorf("unknown operator %q", symbol))
+	return unknown
+}
+
+// child decodes the named member as a node of type T.
+func child[T ast.Node](d *decoder, f fields, name string) T {
+	var zero T
+	node := d.node(f[name])
+	if node == nil {
+		return zero
+	}
+	t, ok := node.(T)
+	if !ok {
+		d.fail(fmt.Errorf("%s: unexpected node type %T", name, node))
+		return zero
+	}
+	return t
+}
+
+// children decodes the named member as a list of nodes of type T.
+func children[T ast.Node](d *decoder, f fields, name string) []T {
+	raw, ok := f[name]
+	if d.err != nil || !ok {
+		return nil
+	}
+	var elems []json.RawMessage
+	if err := json.Unmarshal(raw, &elems); err != nil {
+		d.fail(fmt.Errorf("%s: %w", name, err))
+		return nil
+	}
+	var nodes []T
+	for _, elem := range elems {
+		node := d.node(elem)
+		t, ok := node.(T)
+		if !ok {
+			d.fail(fmt.Errorf("%s: unexpected node type %T", name, node))
+			return nil
+		}
+		nodes = append(nodes, t)
+	}
+	return nodes
+}
+
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(raw) == "null"
+}
@@ -0,0 +1,347 @@
+package astjson
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/TLBuf/papyrus/pkg/ast"
+	"github.com/TLBuf/papyrus/pkg/source"
+	"github.com/TLBuf/papyrus/pkg/types"
+)
+
+// Encode writes the JSON encoding of a script to w.
+func Encode(w io.Writer, script *ast.Script, opts Options) error {
+	e := &encoder{opts: opts}
+	node := e.node(script)
+	if e.err != nil {
+		return e.err
+	}
+	return encodeDocument(w, node, opts)
+}
+
+type encoder struct {
+	opts Options
+	err  error
+}
+
+func (e *encoder) node(node ast.Node) any {
+	if node == nil || reflect.ValueOf(node).IsNil() {
+		return nil
+	}
+	switch n := node.(type) {
+	case *ast.Script:
+		return e.start("Script").
+			add("name", e.node(n.Name)).
+			add("extends", e.node(n.Extends)).
+			add("headerComments", list(e, n.HeaderComments)).
+			add("comment", e.node(n.Comment)).
+			add("isHidden", flag(n.IsHidden)).
+			add("isConditional", flag(n.IsConditional)).
+			add("statements", list(e, n.Statements)).
+			add("location", location(n.SourceRange))
+	case *ast.Identifier:
+		return e.start("Identifier").
+			add("text", n.Text).
+			add("location", location(n.SourceRange))
+	case *ast.DocComment:
+		return e.start("DocComment").
+			add("text", n.Text).
+			add("location", location(n.SourceRange))
+	case *ast.BlockComment:
+		if !e.opts.Comments {
+			return nil
+		}
+		return e.start("BlockComment").
+			add("text", n.Text).
+			add("location", location(n.SourceRange))
+	case *ast.LineComment:
+		if !e.opts.Comments {
+			return nil
+		}
+		return e.start("LineComment").
+			add("text", n.Text).
+			add("location", location(n.SourceRange))
+	case *ast.Import:
+		return e.start("Import").
+			add("name", e.node(n.Name)).
+			add("location", location(n.SourceRange))
+	case *ast.State:
+		return e.start("State").
+			add("name", e.node(n.Name)).
+			add("isAuto", flag(n.IsAuto)).
+			add("invokables", list(e, n.Invokables)).
+			add("location", location(n.SourceRange))
+	case *ast.Event:
+		return e.start("Event").
+			add("name", e.node(n.Name)).
+			add("parameters", list(e, n.Parameters)).
+			add("isNative", flag(n.IsNative)).
+			add("comment", e.node(n.Comment)).
+			add("statements", list(e, n.Statements)).
+			add("location", location(n.SourceRange))
+	case *ast.Function:
+		return e.start("Function").
+			add("name", e.node(n.Name)).
+			add("returnType", e.node(n.ReturnType)).
+			add("parameters", list(e, n.Parameters)).
+			add("isGlobal", flag(n.IsGlobal)).
+			add("isNative", flag(n.IsNative)).
+			add("comment", e.node(n.Comment)).
+			add("statements", list(e, n.Statements)).
+			add("location", location(n.SourceRange))
+	case *ast.Property:
+		params := make([]*ast.Parameter, len(n.Parameters))
+		for i := range n.Parameters {
+			params[i] = &n.Parameters[i]
+		}
+		return e.start("Property").
+			add("name", e.node(n.Name)).
+			add("type", e.node(n.Type)).
+			add("parameters", list(e, params)).
+			add("isHidden", flag(n.IsHidden)).
+			add("isConditional", flag(n.IsConditional)).
+			add("isAuto", flag(n.IsAuto)).
+			add("isReadOnly", flag(n.IsReadOnly)).
+			add("comment", e.node(n.Comment)).
+			add("value", e.node(n.Value)).
+			add("get", e.node(n.Get)).
+			add("set", e.node(n.Set)).
+			add("location", location(n.SourceRange))
+	case *ast.ScriptVariable:
+		return e.start("ScriptVariable").
+			add("type", e.node(n.Type)).
+			add("name", e.node(n.Name)).
+			add("value", e.node(n.Value)).
+			add("isConditional", flag(n.IsConditional)).
+			add("location", location(n.SourceRange))
+	case *ast.FunctionVariable:
+		return e.start("FunctionVariable").
+			add("type", e.node(n.Type)).
+			add("name", e.node(n.Name)).
+			add("value", e.node(n.Value)).
+			add("location", location(n.SourceRange))
+	case *ast.Parameter:
+		var value any
+		if n.Value != nil {
+			value = e.node(*n.Value)
+		}
+		return e.start("Parameter").
+			add("type", e.node(n.Type)).
+			add("name", e.node(n.Name)).
+			add("value", value).
+			add("location", location(n.SourceRange))
+	case *ast.TypeLiteral:
+		return e.start("TypeLiteral").
+			add("type", e.typ(n.Type)).
+			add("location", location(n.SourceRange))
+	case *ast.Assignment:
+		return e.start("Assignment").
+			add("assignee", e.node(n.Assignee)).
+			add("operator", e.node(n.Operator)).
+			add("value", e.node(n.Value)).
+			add("location", location(n.SourceRange))
+	case *ast.AssignmentOperator:
+		return e.start("AssignmentOperator").
+			add("operator", n.Kind.String()).
+			add("location", location(n.SourceRange))
+	case *ast.Return:
+		return e.start("Return").
+			add("value", e.node(n.Value)).
+			add("location", location(n.SourceRange))
+	case *ast.If:
+		return e.start("If").
+			add("condition", e.node(n.Condition)).
+			add("consequence", list(e, n.Consequence)).
+			add("alternative", list(e, n.Alternative)).
+			add("location", location(n.SourceRange))
+	case *ast.While:
+		return e.start("While").
+			add("condition", e.node(n.Condition)).
+			add("statements", list(e, n.Statements)).
+			add("location", location(n.SourceRange))
+	case *ast.ErrorScriptStatement:
+		return e.start("ErrorScriptStatement").
+			add("message", n.Message).
+			add("location", location(n.SourceRange))
+	case *ast.ErrorFunctionStatement:
+		return e.start("ErrorFunctionStatement").
+			add("message", n.Message).
+			add("location", location(n.SourceRange))
+	case *ast.ErrorExpression:
+		return e.start("ErrorExpression").
+			add("message", n.Message).
+			add("location", location(n.SourceRange))
+	case *ast.Access:
+		return e.start("Access").
+			add("value", e.node(n.Value)).
+			add("operator", e.node(n.Operator)).
+			add("name", e.node(n.Name)).
+			add("location", location(n.SourceRange))
+	case *ast.AccessOperator:
+		return e.start("AccessOperator").
+			add("location", location(n.SourceRange))
+	case *ast.Argument:
+		return e.start("Argument").
+			add("name", e.node(n.Name)).
+			add("operator", e.node(n.Operator)).
+			add("value", e.node(n.Value)).
+			add("location", location(n.SourceRange))
+	case *ast.ArrayCreation:
+		return e.start("ArrayCreation").
+			add("newOperator", e.node(n.NewOperator)).
+			add("type", e.node(n.Type)).
+			add("openOperator", e.node(n.OpenOperator)).
+			add("size", e.node(n.Size)).
+			add("closeOperator", e.node(n.CloseOperator)).
+			add("location", location(n.SourceRange))
+	case *ast.NewOperator:
+		return e.start("NewOperator").
+			add("location", location(n.SourceRange))
+	case *ast.ArrayOpenOperator:
+		return e.start("ArrayOpenOperator").
+			add("location", location(n.SourceRange))
+	case *ast.ArrayCloseOperator:
+		return e.start("ArrayCloseOperator").
+			add("location", location(n.SourceRange))
+	case *ast.Binary:
+		return e.start("Binary").
+			add("leftOperand", e.node(n.LeftOperand)).
+			add("operator", e.node(n.Operator)).
+			add("rightOperand", e.node(n.RightOperand)).
+			add("location", location(n.SourceRange))
+	case *ast.BinaryOperator:
+		return e.start("BinaryOperator").
+			add("operator", n.Kind.String()).
+			add("location", location(n.SourceRange))
+	case *ast.Call:
+		var function any
+		if n.Function != nil {
+			function = e.node(*n.Function)
+		}
+		return e.start("Call").
+			add("function", function).
+			add("arguments", list(e, n.Arguments)).
+			add("location", location(n.SourceRange))
+	case *ast.Cast:
+		return e.start("Cast").
+			add("value", e.node(n.Value)).
+			add("operator", e.node(n.Operator)).
+			add("type", e.node(n.Type)).
+			add("location", location(n.SourceRange))
+	case *ast.AsOperator:
+		return e.start("AsOperator").
+			add("location", location(n.SourceRange))
+	case *ast.Index:
+		return e.start("Index").
+			add("value", e.node(n.Value)).
+			add("openOperator", e.node(n.OpenOperator)).
+			add("index", e.node(n.Index)).
+			add("closeOperator", e.node(n.CloseOperator)).
+			add("location", location(n.SourceRange))
+	case *ast.Length:
+		return e.start("Length").
+			add("value", e.node(n.Value)).
+			add("accessOperator", e.node(n.AccessOperator)).
+			add("location", location(n.SourceRange))
+	case *ast.BoolLiteral:
+		return e.start("BoolLiteral").
+			add("value", n.Value).
+			add("location", location(n.SourceRange))
+	case *ast.IntLiteral:
+		return e.start("IntLiteral").
+			add("value", n.Value).
+			add("location", location(n.SourceRange))
+	case *ast.FloatLiteral:
+		return e.start("FloatLiteral").
+			add("value", n.Value).
+			add("location", location(n.SourceRange))
+	case *ast.StringLiteral:
+		return e.start("StringLiteral").
+			add("value", n.Value).
+			add("location", location(n.SourceRange))
+	case *ast.NoneLiteral:
+		return e.start("NoneLiteral").
+			add("location", location(n.SourceRange))
+	case *ast.Parenthetical:
+		return e.start("Parenthetical").
+			add("value", e.node(n.Value)).
+			add("location", location(n.SourceRange))
+	case *ast.Unary:
+		return e.start("Unary").
+			add("operator", e.node(n.Operator)).
+			add("operand", e.node(n.Operand)).
+			add("location", location(n.SourceRange))
+	case *ast.UnaryOperator:
+		return e.start("UnaryOperator").
+			add("operator", n.Kind.String()).
+			add("location", location(n.SourceRange))
+	}
+	e.fail(fmt.Errorf("unsupported node type %T", node))
+	return nil
+}
+
+func (e *encoder) start(kind string) object {
+	return object{{"kind", kind}}
+}
+
+func (e *encoder) fail(err error) {
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+func (e *encoder) typ(t types.Type) any {
+	switch t := t.(type) {
+	case nil:
+		return nil
+	case types.Bool:
+		return object{{"kind", "Bool"}}
+	case types.Int:
+		return object{{"kind", "Int"}}
+	case types.Float:
+		return object{{"kind", "Float"}}
+	case types.String:
+		return object{{"kind", "String"}}
+	case types.Object:
+		return object{{"kind", "Object"}, {"name", t.Name}}
+	case types.Array:
+		return object{{"kind", "Array"}}.add("element", e.typ(t.ElementType))
+	}
+	e.fail(fmt.Errorf("unsupported type %T", t))
+	return nil
+}
+
+// list returns the encoded nodes or nil if there are none.
+func list[T ast.Node](e *encoder, nodes []T) any {
+	if len(nodes) == 0 {
+		return nil
+	}
+	encoded := make([]any, 0, len(nodes))
+	for _, n := range nodes {
+		if v := e.node(n); v != nil {
+			encoded = append(encoded, v)
+		}
+	}
+	if len(encoded) == 0 {
+		return nil
+	}
+	return encoded
+}
+
+// flag returns true if v is true or nil otherwise.
+func flag(v bool) any {
+	if !v {
+		return nil
+	}
+	return true
+}
+
+func location(r source.Range) object {
+	return object{
+		{"offset", r.ByteOffset},
+		{"length", r.Length},
+		{"line", r.Line},
+		{"column", r.Column},
+	}
+}
@@ -2,7 +2,10 @@
 package lexer
 
 import (
+	"bytes"
 	"fmt"
+	"iter"
+	"math"
 	"unicode/utf8"
 
 	"github.com/TLBuf/papyrus/pkg/source"
@@ -25,20 +28,33 @@ func (e Error) Error() string {
 
 // Lexer provides the ability to lex a Papyrus script.
 type Lexer struct {
-	file      *source.File
-	position  int
-	next      int
-	character rune
-	column    int
-	line      int
+	file  *source.File
+	lines *source.LineIndex
+	// line is the line of the last token, and lineStart and nextLineStart are
+	// the offsets of the first character of that line and of the line after it,
+	// so that tokens are located without searching lines.
+	line          int
+	lineStart     int
+	nextLineStart int
+	position      int
+	next          int
+	character     rune
+	warnings      []Error
+
+	continuations []source.Range
 }
 
 // New returns a [*Lexer] initialized for the given text.
 func New(file *source.File) *Lexer {
 	l := &Lexer{
-		file:   file,
-		line:   1,
-		column: 0,
+		file:  file,
+		lines: file.Lines(),
+	}
+	l.setLine(1)
+	if file.HasBOM() {
+		// The byte order mark is not part of the script and does not occupy a
+		// column.
+		l.next = utf8.RuneLen('\uFEFF')
 	}
 	l.readChar()
 	return l
@@ -52,16 +68,7 @@ func (l *Lexer) NextToken() (token.Token, error) {
 	l.skipWhitespace()
 	switch l.character {
 	case 0:
-		tok = token.Token{
-			Type: token.EOF,
-			SourceRange: source.Range{
-				File:       l.file,
-				ByteOffset: l.position,
-				Length:     0,
-				Line:       l.line,
-				Column:     l.column,
-			},
-		}
+		tok = l.newTokenWithRange(token.EOF, l.position, 0)
 	case '(':
 		tok = l.newToken(token.LParen)
 	case ')':
@@ -77,104 +84,65 @@ func (l *Lexer) NextToken() (token.Token, error) {
 	case '\n':
 		tok = l.newToken(token.Newline)
 	case '\r':
-		column := l.column
-		l.readChar()
-		if l.character == '\n' {
-			tok = l.newTokenWithRange(token.Newline, l.position-1, l.next-l.position+1, l.line, column)
+		if l.peekChar() == '\n' {
+			tok = l.newTokenWithRange(token.Newline, l.position, 2)
+			l.readChar()
+		} else {
+			tok = l.newToken(token.Newline)
+			l.warnLoneReturn()
 		}
-		errTok := l.newTokenWithRange(token.Illegal, l.position-1, 1, l.line, column)
-		return errTok, Error{Message: "expected a newline after carriage return", Location: errTok.SourceRange}
 	case '\\':
-		column := l.column
-		l.readChar()
-		tok, err := l.NextToken()
-		if err != nil {
-			return tok, err
+		// Line continuation, the newline that follows is not a token.
+		start := l.newToken(token.Illegal)
+		if !l.continuesLine() {
+			// Leave whatever follows to be lexed normally.
+			l.readChar()
+			return start, Error{Message: "expected a newline after line continuation '\\'", Location: start.SourceRange}
 		}
-		if tok.Type != token.Newline {
-			errTok := l.newTokenWithRange(token.Illegal, tok.SourceRange.ByteOffset, 1, l.line, column)
-			return errTok, Error{Message: "expected a newline immediately after '/'", Location: errTok.SourceRange}
+		l.readChar()
+		l.skipWhitespace()
+		end := l.newToken(token.Newline)
+		if l.character == '\r' {
+			if l.peekChar() == '\n' {
+				l.readChar()
+				end.SourceRange.Length = 2
+			} else {
+				l.warnLoneReturn()
+			}
 		}
+		l.readChar()
+		l.continuations = append(l.continuations, source.Span(start.SourceRange, end.SourceRange))
 		return l.NextToken()
 	case '=':
-		column := l.column
-		l.readChar()
-		if l.character == '=' {
-			tok = l.newTokenWithRange(token.Equal, l.position-1, 2, l.line, column)
-		}
-		return l.newTokenWithRange(token.Assign, l.position-1, 1, l.line, column), nil
+		return l.readOperator(token.Assign, '=', token.Equal), nil
 	case '+':
-		column := l.column
-		l.readChar()
-		if l.character == '=' {
-			tok = l.newTokenWithRange(token.AssignAdd, l.position-1, 2, l.line, column)
-		}
-		return l.newTokenWithRange(token.Add, l.position-1, 1, l.line, column), nil
+		return l.readOperator(token.Add, '=', token.AssignAdd), nil
 	case '-':
-		column := l.column
-		l.readChar()
-		if l.character == '=' {
-			tok = l.newTokenWithRange(token.AssignSubtract, l.position-1, 2, l.line, column)
-		}
-		return l.newTokenWithRange(token.Subtract, l.position-1, 1, l.line, column), nil
+		return l.readOperator(token.Subtract, '=', token.AssignSubtract), nil
 	case '*':
-		column := l.column
-		l.readChar()
-		if l.character == '=' {
-			tok = l.newTokenWithRange(token.AssignMultiply, l.position-1, 2, l.line, column)
-		}
-		return l.newTokenWithRange(token.Multiply, l.position-1, 1, l.line, column), nil
+		return l.readOperator(token.Multiply, '=', token.AssignMultiply), nil
 	case '/':
-		column := l.column
-		l.readChar()
-		if l.character == '=' {
-			tok = l.newTokenWithRange(token.AssignDivide, l.position-1, 2, l.line, column)
-		}
-		return l.newTokenWithRange(token.Divide, l.position-1, 1, l.line, column), nil
+		return l.readOperator(token.Divide, '=', token.AssignDivide), nil
 	case '%':
-		column := l.column
-		l.readChar()
-		if l.character == '=' {
-			tok = l.newTokenWithRange(token.AssignModulo, l.position-1, 2, l.line, column)
-		}
-		return l.newTokenWithRange(token.Modulo, l.position-1, 1, l.line, column), nil
+		return l.readOperator(token.Modulo, '=', token.AssignModulo), nil
 	case '!':
-		column := l.column
-		l.readChar()
-		if l.character == '=' {
-			tok = l.newTokenWithRange(token.NotEqual, l.position-1, 2, l.line, column)
-		}
-		return l.newTokenWithRange(token.LogicalNot, l.position-1, 1, l.line, column), nil
+		return l.readOperator(token.LogicalNot, '=', token.NotEqual), nil
 	case '>':
-		column := l.column
-		l.readChar()
-		if l.character == '=' {
-			tok = l.newTokenWithRange(token.GreaterOrEqual, l.position-1, 2, l.line, column)
-		}
-		return l.newTokenWithRange(token.Greater, l.position-1, 1, l.line, column), nil
+		return l.readOperator(token.Greater, '=', token.GreaterOrEqual), nil
 	case '<':
-		column := l.column
-		l.readChar()
-		if l.character == '=' {
-			tok = l.newTokenWithRange(token.LessOrEqual, l.position-1, 2, l.line, column)
-		}
-		return l.newTokenWithRange(token.Less, l.position-1, 1, l.line, column), nil
+		return l.readOperator(token.Less, '=', token.LessOrEqual), nil
 	case '|':
-		column := l.column
-		l.readChar()
-		if l.character == '|' {
-			tok = l.newTokenWithRange(token.LogicalOr, l.position-1, 2, l.line, column)
+		tok := l.readOperator(token.Illegal, '|', token.LogicalOr)
+		if tok.Type == token.Illegal {
+			return tok, Error{Message: "'|' is not a valid operator", Location: tok.SourceRange}
 		}
-		errTok := l.newTokenWithRange(token.Illegal, l.position-1, 1, l.line, column)
-		return errTok, Error{Message: "'|' is not a valid operator", Location: errTok.SourceRange}
+		return tok, nil
 	case '&':
-		column := l.column
-		l.readChar()
-		if l.character == '&' {
-			tok = l.newTokenWithRange(token.LogicalAnd, l.position-1, 2, l.line, column)
+		tok := l.readOperator(token.Illegal, '&', token.LogicalAnd)
+		if tok.Type == token.Illegal {
+			return tok, Error{Message: "'&' is not a valid operator", Location: tok.SourceRange}
 		}
-		errTok := l.newTokenWithRange(token.Illegal, l.position-1, 1, l.line, column)
-		return errTok, Error{Message: "'&' is not a valid operator", Location: errTok.SourceRange}
+		return tok, nil
 	case '{', ';':
 		return l.readComment()
 	case '"':
@@ -186,28 +154,58 @@ func (l *Lexer) NextToken() (token.Token, error) {
 			return l.readNumber()
 		} else {
 			tok = l.newToken(token.Illegal)
+			message := "failed to lex any token"
+			if l.character == utf8.RuneError && tok.SourceRange.Length == 1 {
+				message = "encountered invalid UTF-8"
+			}
 			l.readChar()
-			return tok, Error{Message: "failed to lex any token", Location: tok.SourceRange}
+			return tok, Error{Message: message, Location: tok.SourceRange}
 		}
 	}
 	l.readChar()
 	return tok, nil
 }
 
-func (l *Lexer) newToken(t token.Type) token.Token {
-	return token.Token{
-		Type: t,
-		SourceRange: source.Range{
-			File:       l.file,
-			ByteOffset: l.position,
-			Length:     l.next - l.position,
-			Line:       l.line,
-			Column:     l.column,
-		},
+// Tokens returns an iterator over the remaining tokens in the file, including
+// comments and newlines, that ends after yielding the EOF token.
+//
+// Each token is yielded with the error, if any, that [Lexer.NextToken] returned
+// for it. Lexing continues after errors: text that could not be lexed is
+// yielded as an Illegal token and the iterator resumes with the text that
+// follows it.
+func (l *Lexer) Tokens() iter.Seq2[token.Token, error] {
+	return func(yield func(token.Token, error) bool) {
+		for {
+			tok, err := l.NextToken()
+			if !yield(tok, err) || tok.Type == token.EOF {
+				return
+			}
+		}
 	}
 }
 
-func (l *Lexer) newTokenWithRange(t token.Type, byteOffset, length, line, column int) token.Token {
+// Continuations returns the locations of the line continuations in the text
+// lexed so far. Each spans from the \ through the end of the newline it
+// continues.
+func (l *Lexer) Continuations() []source.Range {
+	return l.continuations
+}
+
+// Warnings returns the non-fatal issues found in the text lexed so far (e.g. a
+// lone carriage return used as a line break).
+func (l *Lexer) Warnings() []Error {
+	return l.warnings
+}
+
+func (l *Lexer) newToken(t token.Type) token.Token {
+	return l.newTokenWithRange(t, l.position, l.next-l.position)
+}
+
+// newTokenWithRange returns a token with its line and column taken from the
+// file's [source.LineIndex], so the lexer and everything that maps offsets to
+// positions agree on what a line break is.
+func (l *Lexer) newTokenWithRange(t token.Type, byteOffset, length int) token.Token {
+	line, column := l.locate(byteOffset)
 	return token.Token{
 		Type: t,
 		SourceRange: source.Range{
@@ -220,21 +218,53 @@ func (l *Lexer) newTokenWithRange(t token.Type, byteOffset, length, line, column
 	}
 }
 
+// locate returns the line and column of a byte offset as [source.LineIndex]
+// does, moving forward from the line of the last token.
+func (l *Lexer) locate(offset int) (line, column int) {
+	if offset < l.lineStart {
+		return l.lines.Position(offset)
+	}
+	for offset >= l.nextLineStart {
+		l.setLine(l.line + 1)
+	}
+	return l.line, utf8.RuneCount(l.file.Text[l.lineStart:offset]) + 1
+}
+
+// setLine makes line the line that tokens are located from.
+func (l *Lexer) setLine(line int) {
+	l.line = line
+	l.lineStart = l.lines.Line(line).ByteOffset
+	l.nextLineStart = math.MaxInt
+	if line < l.lines.Count() {
+		l.nextLineStart = l.lines.Line(line + 1).ByteOffset
+	}
+}
+
+// readOperator reads a one character operator of type single or, if the
+// character that follows it is second, a two character operator of type double.
+func (l *Lexer) readOperator(single token.Type, second rune, double token.Type) token.Token {
+	start := l.position
+	l.readChar()
+	if l.character != second {
+		return l.newTokenWithRange(single, start, 1)
+	}
+	l.readChar()
+	return l.newTokenWithRange(double, start, 2)
+}
+
 func (l *Lexer) readIdentifier() token.Token {
 	start := l.position
-	column := l.column
 	l.readChar()
 	for isLetter(l.character) || isDigit(l.character) {
 		l.readChar()
 	}
 	text := l.file.Text[start:l.position]
-	return l.newTokenWithRange(token.LookupIdentifier(string(text)), start, l.position-start, l.line, column)
+	return l.newTokenWithRange(token.LookupIdentifier(string(text)), start, l.position-start)
 }
 
 func (l *Lexer) readNumber() (token.Token, error) {
 	start := l.position
 	first := l.character
-	column := l.column
 	l.readChar()
 	if first == '0' && (l.character == 'x' || l.character == 'X') {
 		// Hex Int
@@ -242,11 +272,16 @@ func (l *Lexer) readNumber() (token.Token, error) {
 		for isHexDigit(l.character) {
 			l.readChar()
 		}
-		tok := l.newTokenWithRange(token.IntLiteral, start, l.position-start, l.line, column)
+		tok := l.newTokenWithRange(token.IntLiteral, start, l.position-start)
 		if l.file.Text[l.position-1] == 'x' || l.file.Text[l.position-1] == 'X' {
 			tok.Type = token.Illegal
 			return tok, Error{Message: fmt.Sprintf("expected a digit to follow the %s in a hex int literal", string(l.file.Text[l.position-1])), Location: tok.SourceRange}
 		}
+		// Ints are 32 bits, so at most 8 hex digits are significant.
+		if digits := bytes.TrimLeft(l.file.Text[start+2:l.position], "0"); len(digits) > 8 {
+			tok.Type = token.Illegal
+			return tok, Error{Message: fmt.Sprintf("hex int literal %s does not fit in 32 bits", tok.SourceRange.Text()), Location: tok.SourceRange}
+		}
 		return tok, nil
 	}
 	isFloat := false
@@ -256,75 +291,97 @@ func (l *Lexer) readNumber() (token.Token, error) {
 		}
 		l.readChar()
 	}
-	tok := l.newTokenWithRange(token.IntLiteral, start, l.position-start, l.line, column)
 	if l.file.Text[l.position-1] == '.' {
 		// Number ends with a dot?
-		tok.Type = token.Illegal
+		tok := l.newTokenWithRange(token.Illegal, start, l.position-start)
 		return tok, Error{Message: "expected a digit to follow the dot in a float literal", Location: tok.SourceRange}
 	}
+	if l.character == 'e' || l.character == 'E' {
+		// Scientific notation with an optional sign (e.g. 1e5 or 2.5E-3).
+		isFloat = true
+		l.readChar()
+		if l.character == '+' || l.character == '-' {
+			l.readChar()
+		}
+		if !isDigit(l.character) {
+			tok := l.newTokenWithRange(token.Illegal, start, l.position-start)
+			return tok, Error{Message: "expected a digit in the exponent of a float literal", Location: tok.SourceRange}
+		}
+		for isDigit(l.character) {
+			l.readChar()
+		}
+	}
+	t := token.IntLiteral
 	if isFloat {
-		tok.Type = token.FloatLiteral
+		t = token.FloatLiteral
 	}
-	return tok, nil
+	return l.newTokenWithRange(t, start, l.position-start), nil
 }
 
 func (l *Lexer) readString() (token.Token, error) {
 	start := l.position
-	column := l.column
-	l.readChar()
-	escaping := false
 	for {
 		l.readChar()
-		if l.character == 0 {
-			break
-		}
-		if l.character == '\\' {
-			escaping = true
-			continue
-		}
-		if escaping {
-			if l.character == 'n' || l.character == 't' || l.character == '"' || l.character == '\\' {
-				escaping = false
+		switch l.character {
+		case 0:
+			tok := l.newTokenWithRange(token.Illegal, start, l.position-start)
+			return tok, Error{Message: "reached end of file while reading string literal", Location: tok.SourceRange}
+		case '"':
+			l.readChar()
+			return l.newTokenWithRange(token.StringLiteral, start, l.position-start), nil
+		case '\\':
+			l.readChar()
+			switch l.character {
+			case 'n', 't', '"', '\\':
 				continue
+			case 0:
+				tok := l.newTokenWithRange(token.Illegal, start, l.position-start)
+				return tok, Error{Message: "reached end of file while reading string literal", Location: tok.SourceRange}
 			}
-			tok := l.newTokenWithRange(token.Illegal, start, l.position-start, l.line, column)
-			return tok, Error{Message: fmt.Sprintf("encountered an invalid string escape sequence: \\%s", string(l.character)), Location: tok.SourceRange}
-		}
-		if l.character == '"' {
-			break
+			message := fmt.Sprintf("encountered an invalid string escape sequence: \\%s", string(l.character))
+			l.skipString()
+			tok := l.newTokenWithRange(token.Illegal, start, l.position-start)
+			return tok, Error{Message: message, Location: tok.SourceRange}
 		}
 	}
-	tok := l.newTokenWithRange(token.StringLiteral, start, l.position-start, l.line, column)
-	if l.character == 0 {
-		tok.Type = token.Illegal
-		return tok, Error{Message: "reached end of file while reading string literal", Location: tok.SourceRange}
+}
+
+// skipString skips the remainder of a string literal that cannot be lexed,
+// stopping after its closing quote or before the end of the line, so that
+// lexing resumes after the literal rather than inside it.
+func (l *Lexer) skipString() {
+	for {
+		switch l.character {
+		case '"':
+			l.readChar()
+			return
+		case '\n', '\r', 0:
+			return
+		case '\\':
+			// Skip the escaped character, which may be a quote.
+			l.readChar()
+			if l.character == '\n' || l.character == '\r' || l.character == 0 {
+				return
+			}
+		}
+		l.readChar()
 	}
-	l.readChar()
-	return tok, nil
 }
 
 func (l *Lexer) readComment() (token.Token, error) {
-	tok := token.Token{
-		Type: token.Illegal,
-		SourceRange: source.Range{
-			File:       l.file,
-			ByteOffset: l.position,
-			Line:       l.line,
-			Column:     l.column,
-		},
-	}
+	tok := l.newTokenWithRange(token.Illegal, l.position, 0)
 	if l.character == '{' {
 		// Doc comment
 		for l.character != 0 && l.character != '}' {
 			l.readChar()
 		}
 
+		tok.Type = token.DocComment
 		if l.character == 0 {
 			tok.SourceRange.Length = l.position - tok.SourceRange.ByteOffset
-			return tok, Error{Message: "reached end of file while reading doc comment", Location: tok.SourceRange}
+			return tok, Error{Message: "unterminated doc comment, expected '}' before end of file", Location: tok.SourceRange}
 		}
 		l.readChar()
-		tok.Type = token.DocComment
 		tok.SourceRange.Length = l.position - tok.SourceRange.ByteOffset
 		return tok, nil
 	}
@@ -345,17 +402,17 @@ func (l *Lexer) readComment() (token.Token, error) {
 			l.readChar()
 		}
 
+		tok.Type = token.BlockComment
 		if l.character == 0 {
 			tok.SourceRange.Length = l.position - tok.SourceRange.ByteOffset
-			return tok, Error{Message: "reached end of file while reading block comment", Location: tok.SourceRange}
+			return tok, Error{Message: "unterminated block comment, expected '/;' before end of file", Location: tok.SourceRange}
 		}
 		l.readChar()
-		tok.Type = token.BlockComment
 		tok.SourceRange.Length = l.position - tok.SourceRange.ByteOffset
 		return tok, nil
 	}
 	// Line comment
-	for l.character != 0 && l.character != '\n' {
+	for l.character != 0 && l.character != '\n' && l.character != '\r' {
 		l.readChar()
 	}
 	tok.Type = token.LineComment
@@ -363,43 +420,107 @@ func (l *Lexer) readComment() (token.Token, error) {
 	return tok, nil
 }
 
+// continuesLine returns true if the current character is followed by nothing
+// but whitespace before the end of the line, without consuming anything.
+func (l *Lexer) continuesLine() bool {
+	for i := l.next; i < len(l.file.Text); i++ {
+		switch l.file.Text[i] {
+		case ' ', '\t':
+			continue
+		case '\n', '\r':
+			return true
+		}
+		return false
+	}
+	return false
+}
+
 func (l *Lexer) skipWhitespace() {
 	for l.character == ' ' || l.character == '\t' {
 		l.readChar()
 	}
 }
 
-func (l *Lexer) readChar() error {
+func (l *Lexer) readChar() {
 	width := 1
-	if l.character == '\n' {
-		l.line++
-		l.column = 0
+	if l.next > len(l.file.Text) {
+		// Already at the end of the file.
+		return
 	}
-	if l.next >= len(l.file.Text) {
+	if l.next == len(l.file.Text) {
+		// The end of file is positioned immediately after the last character.
 		l.character = 0
-		l.column = 1
 	} else {
-		r, w := utf8.DecodeRune(l.file.Text[l.next:])
-		if r == utf8.RuneError {
-			return fmt.Errorf("encountered invalid UTF-8 at byte %d", l.next)
+		if b := l.file.Text[l.next]; b < utf8.RuneSelf {
+			l.character = rune(b)
+		} else {
+			// Invalid UTF-8 decodes as a one byte utf8.RuneError.
+			l.character, width = utf8.DecodeRune(l.file.Text[l.next:])
 		}
-		l.character = r
-		width = w
-		l.column++
 	}
 	l.position = l.next
 	l.next += width
-	return nil
+}
+
+// warnLoneReturn records a warning for the current character, a carriage
+// return read as a line break without a newline after it.
+//
+// Carriage returns within comments and string literals are part of their text
+// and are not warned about.
+func (l *Lexer) warnLoneReturn() {
+	l.warnings = append(l.warnings, Error{
+		Message:  "carriage return is not followed by a newline, treating it as a line break",
+		Location: l.newToken(token.Newline).SourceRange,
+	})
+}
+
+// peekChar returns the byte after the current character or 0 at the end of the
+// file.
+func (l *Lexer) peekChar() byte {
+	if l.next >= len(l.file.Text) {
+		return 0
+	}
+	return l.file.Text[l.next]
+}
+
+// class is a set of flags describing how the lexer treats an ASCII character.
+type class uint8
+
+const (
+	letter class = 1 << iota
+	digit
+	hexDigit
+)
+
+// classes holds the class of every ASCII character.
+var classes = func() (c [utf8.RuneSelf]class) {
+	for ch := 'a'; ch <= 'z'; ch++ {
+		c[ch] |= letter
+		c[ch-'a'+'A'] |= letter
+	}
+	c['_'] |= letter
+	for ch := '0'; ch <= '9'; ch++ {
+		c[ch] |= digit | hexDigit
+	}
+	for ch := 'a'; ch <= 'f'; ch++ {
+		c[ch] |= hexDigit
+		c[ch-'a'+'A'] |= hexDigit
+	}
+	return c
+}()
+
+func is(char rune, class class) bool {
+	return char >= 0 && char < utf8.RuneSelf && classes[char]&class != 0
 }
 
 func isLetter(char rune) bool {
-	return 'a' <= char && char <= 'z' || 'A' <= char && char <= 'Z' || char == '_'
+	return is(char, letter)
 }
 
 func isDigit(char rune) bool {
-	return '0' <= char && char <= '9'
+	return is(char, digit)
 }
 
 func isHexDigit(char rune) bool {
-	return '0' <= char && char <= '9' || 'a' <= char && char <= 'f' || 'A' <= char && char <= 'F'
+	return is(char, hexDigit)
 }
@@ -1,8 +1,13 @@
 package lexer_test
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
 	"github.com/TLBuf/papyrus/pkg/lexer"
 	"github.com/TLBuf/papyrus/pkg/source"
 	"github.com/TLBuf/papyrus/pkg/token"
@@ -116,3 +121,786 @@ EndState ; Comment
 		}
 	}
 }
+
+func TestUnterminatedComment(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		skip        int
+		wantType    token.Type
+		wantText    string
+		wantOffset  int
+		wantLine    int
+		wantColumn  int
+		wantMessage string
+	}{
+		{
+			name:        "doc_comment_header",
+			text:        "ScriptName Foo\n{A doc\ncomment",
+			skip:        3,
+			wantType:    token.DocComment,
+			wantText:    "{A doc\ncomment",
+			wantOffset:  15,
+			wantLine:    2,
+			wantColumn:  1,
+			wantMessage: "unterminated doc comment, expected '}' before end of file",
+		},
+		{
+			name:        "block_comment",
+			text:        "Int a = 1\n;/ A\nblock /",
+			skip:        5,
+			wantType:    token.BlockComment,
+			wantText:    ";/ A\nblock /",
+			wantOffset:  10,
+			wantLine:    2,
+			wantColumn:  1,
+			wantMessage: "unterminated block comment, expected '/;' before end of file",
+		},
+		{
+			name:        "single_brace",
+			text:        "{",
+			wantType:    token.DocComment,
+			wantText:    "{",
+			wantOffset:  0,
+			wantLine:    1,
+			wantColumn:  1,
+			wantMessage: "unterminated doc comment, expected '}' before end of file",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := lexer.New(&source.File{Text: []byte(test.text)})
+			for i := range test.skip {
+				if _, err := l.NextToken(); err != nil {
+					t.Fatalf("unexpected error at token %d: %v", i, err)
+				}
+			}
+			tok, err := l.NextToken()
+			if err == nil {
+				t.Fatalf("NextToken() returned no error, want %q", test.wantMessage)
+			}
+			lexErr, ok := err.(lexer.Error)
+			if !ok {
+				t.Fatalf("NextToken() returned %T, want lexer.Error", err)
+			}
+			if lexErr.Message != test.wantMessage {
+				t.Errorf("error message mismatch, want: %q, got: %q", test.wantMessage, lexErr.Message)
+			}
+			if lexErr.Location != tok.SourceRange {
+				t.Errorf("error location mismatch, want: %+v, got: %+v", tok.SourceRange, lexErr.Location)
+			}
+			if tok.Type != test.wantType {
+				t.Errorf("token type mismatch, want: %v, got: %v", test.wantType, tok.Type)
+			}
+			if got := string(tok.SourceRange.Text()); got != test.wantText {
+				t.Errorf("token text mismatch, want: %q, got: %q", test.wantText, got)
+			}
+			if tok.SourceRange.ByteOffset != test.wantOffset {
+				t.Errorf("token byte offset mismatch, want: %d, got: %d", test.wantOffset, tok.SourceRange.ByteOffset)
+			}
+			if tok.SourceRange.Line != test.wantLine {
+				t.Errorf("token line mismatch, want: %d, got: %d", test.wantLine, tok.SourceRange.Line)
+			}
+			if tok.SourceRange.Column != test.wantColumn {
+				t.Errorf("token column mismatch, want: %d, got: %d", test.wantColumn, tok.SourceRange.Column)
+			}
+			eof, err := l.NextToken()
+			if err != nil {
+				t.Fatalf("unexpected error after unterminated comment: %v", err)
+			}
+			if eof.Type != token.EOF {
+				t.Errorf("token type mismatch after unterminated comment, want: %v, got: %v", token.EOF, eof.Type)
+			}
+		})
+	}
+}
+
+func TestEOFPosition(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		wantOffset int
+		wantLine   int
+		wantColumn int
+	}{
+		{"empty", "", 0, 1, 1},
+		{"no_trailing_newline", "ScriptName Foo", 14, 1, 15},
+		{"trailing_newline", "ScriptName Foo\n", 15, 2, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := lexer.New(&source.File{Text: []byte(test.text)})
+			var tok token.Token
+			for tok.Type != token.EOF {
+				var err error
+				if tok, err = l.NextToken(); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			// Reading past the end of the file must not move the EOF position.
+			again, err := l.NextToken()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, got := range []token.Token{tok, again} {
+				if got.Type != token.EOF {
+					t.Errorf("token type mismatch, want: %v, got: %v", token.EOF, got.Type)
+				}
+				if got.SourceRange.ByteOffset != test.wantOffset {
+					t.Errorf("token byte offset mismatch, want: %d, got: %d", test.wantOffset, got.SourceRange.ByteOffset)
+				}
+				if got.SourceRange.Length != 0 {
+					t.Errorf("token length mismatch, want: 0, got: %d", got.SourceRange.Length)
+				}
+				if got.SourceRange.Line != test.wantLine {
+					t.Errorf("token line mismatch, want: %d, got: %d", test.wantLine, got.SourceRange.Line)
+				}
+				if got.SourceRange.Column != test.wantColumn {
+					t.Errorf("token column mismatch, want: %d, got: %d", test.wantColumn, got.SourceRange.Column)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenTypesExhaustive(t *testing.T) {
+	for typ := token.Type(0); typ.String() != "<unknown>"; typ++ {
+		text := typ.Text()
+		if text == "" {
+			continue
+		}
+		t.Run(typ.String(), func(t *testing.T) {
+			l := lexer.New(&source.File{Text: []byte(text)})
+			tok, err := l.NextToken()
+			if err != nil {
+				t.Fatalf("NextToken() returned an unexpected error: %v", err)
+			}
+			if tok.Type != typ {
+				t.Errorf("token type mismatch, want: %v, got: %v", typ, tok.Type)
+			}
+			if got := string(tok.SourceRange.Text()); got != text {
+				t.Errorf("token text mismatch, want: %q, got: %q", text, got)
+			}
+			eof, err := l.NextToken()
+			if err != nil {
+				t.Fatalf("NextToken() returned an unexpected error: %v", err)
+			}
+			if eof.Type != token.EOF {
+				t.Errorf("token type mismatch after %v, want: %v, got: %v", typ, token.EOF, eof.Type)
+			}
+		})
+	}
+}
+
+func TestOperators(t *testing.T) {
+	text := "a+=b==c!=d>=e<=f||g&&h-=i*=j/=k%=l+-*/%!<>="
+	want := []struct {
+		wantType token.Type
+		wantText string
+	}{
+		{token.Identifier, "a"},
+		{token.AssignAdd, "+="},
+		{token.Identifier, "b"},
+		{token.Equal, "=="},
+		{token.Identifier, "c"},
+		{token.NotEqual, "!="},
+		{token.Identifier, "d"},
+		{token.GreaterOrEqual, ">="},
+		{token.Identifier, "e"},
+		{token.LessOrEqual, "<="},
+		{token.Identifier, "f"},
+		{token.LogicalOr, "||"},
+		{token.Identifier, "g"},
+		{token.LogicalAnd, "&&"},
+		{token.Identifier, "h"},
+		{token.AssignSubtract, "-="},
+		{token.Identifier, "i"},
+		{token.AssignMultiply, "*="},
+		{token.Identifier, "j"},
+		{token.AssignDivide, "/="},
+		{token.Identifier, "k"},
+		{token.AssignModulo, "%="},
+		{token.Identifier, "l"},
+		{token.Add, "+"},
+		{token.Subtract, "-"},
+		{token.Multiply, "*"},
+		{token.Divide, "/"},
+		{token.Modulo, "%"},
+		{token.LogicalNot, "!"},
+		{token.Less, "<"},
+		{token.GreaterOrEqual, ">="},
+		{token.EOF, ""},
+	}
+	l := lexer.New(&source.File{Text: []byte(text)})
+	for i, tt := range want {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("unexpected error at token %d: %v", i, err)
+		}
+		if tok.Type != tt.wantType {
+			t.Errorf("token type mismatch at token %d, want: %v, got: %v", i, tt.wantType, tok.Type)
+		}
+		if got := string(tok.SourceRange.Text()); got != tt.wantText {
+			t.Errorf("token text mismatch at token %d, want: %q, got: %q", i, tt.wantText, got)
+		}
+	}
+}
+
+func TestInvalidOperator(t *testing.T) {
+	for _, text := range []string{"|", "&", "a | b", "a & b"} {
+		l := lexer.New(&source.File{Text: []byte(text)})
+		var err error
+		for tok := (token.Token{}); tok.Type != token.EOF && err == nil; {
+			tok, err = l.NextToken()
+		}
+		if err == nil {
+			t.Errorf("lexing %q returned no error", text)
+		}
+	}
+}
+
+func TestLineEndings(t *testing.T) {
+	lines := []string{
+		"ScriptName Foo ; comment",
+		"{ doc",
+		"comment }",
+		"Import Bar",
+	}
+	type position struct {
+		Type   token.Type
+		Text   string
+		Line   int
+		Column int
+	}
+	// Line breaks within comments are part of their text.
+	newlines := strings.NewReplacer("\r\n", "\n", "\r", "\n")
+	lex := func(t *testing.T, text string) ([]position, []lexer.Error) {
+		t.Helper()
+		l := lexer.New(&source.File{Text: []byte(text)})
+		var got []position
+		for {
+			tok, err := l.NextToken()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tok.Type == token.EOF {
+				break
+			}
+			if tok.Type == token.Newline {
+				continue
+			}
+			got = append(got, position{
+				Type:   tok.Type,
+				Text:   newlines.Replace(string(tok.SourceRange.Text())),
+				Line:   tok.SourceRange.Line,
+				Column: tok.SourceRange.Column,
+			})
+		}
+		return got, l.Warnings()
+	}
+	want, _ := lex(t, strings.Join(lines, "\n"))
+	tests := []struct {
+		name         string
+		text         string
+		wantWarnings []int
+	}{
+		{
+			name: "crlf",
+			text: strings.Join(lines, "\r\n"),
+		},
+		{
+			name:         "cr",
+			text:         strings.Join(lines, "\r"),
+			wantWarnings: []int{24, 40},
+		},
+		{
+			name:         "mixed",
+			text:         lines[0] + "\r\n" + lines[1] + "\n" + lines[2] + "\r" + lines[3],
+			wantWarnings: []int{41},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, warnings := lex(t, test.text)
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("token positions mismatch (-want +got):\n%s", diff)
+			}
+			var offsets []int
+			for _, w := range warnings {
+				offsets = append(offsets, w.Location.ByteOffset)
+				if w.Location.Length != 1 {
+					t.Errorf("warning length mismatch, want: 1, got: %d", w.Location.Length)
+				}
+			}
+			if diff := cmp.Diff(test.wantWarnings, offsets); diff != "" {
+				t.Errorf("warning offsets mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoneCarriageReturnWarnings(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		wantWarnings []int
+	}{
+		{
+			name:         "between_tokens",
+			text:         "a\rb",
+			wantWarnings: []int{1},
+		},
+		{
+			name:         "after_line_comment",
+			text:         "a ; comment\rb",
+			wantWarnings: []int{11},
+		},
+		{
+			name:         "line_continuation",
+			text:         "a \\\rb",
+			wantWarnings: []int{3},
+		},
+		{
+			name: "doc_comment",
+			text: "{a\rb}",
+		},
+		{
+			name: "block_comment",
+			text: ";/a\rb/;",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := lexer.New(&source.File{Text: []byte(test.text)})
+			for tok, err := range l.Tokens() {
+				if err != nil {
+					t.Fatalf("unexpected error at %d: %v", tok.SourceRange.ByteOffset, err)
+				}
+			}
+			var offsets []int
+			for _, w := range l.Warnings() {
+				offsets = append(offsets, w.Location.ByteOffset)
+			}
+			if diff := cmp.Diff(test.wantWarnings, offsets); diff != "" {
+				t.Errorf("warning offsets mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewlineTokens(t *testing.T) {
+	l := lexer.New(&source.File{Text: []byte("a\r\nb\rc\nd")})
+	var got []source.Range
+	for {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if tok.Type == token.EOF {
+			break
+		}
+		if tok.Type == token.Newline {
+			tok.SourceRange.File = nil
+			got = append(got, tok.SourceRange)
+		}
+	}
+	want := []source.Range{
+		{ByteOffset: 1, Length: 2, Line: 1, Column: 2},
+		{ByteOffset: 4, Length: 1, Line: 2, Column: 2},
+		{ByteOffset: 6, Length: 1, Line: 3, Column: 2},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("newline tokens mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestByteOrderMark(t *testing.T) {
+	l := lexer.New(&source.File{Text: []byte("\xEF\xBB\xBFScriptName Foo")})
+	tok, err := l.NextToken()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := token.Token{
+		Type:        token.ScriptName,
+		SourceRange: source.Range{ByteOffset: 3, Length: 10, Line: 1, Column: 1},
+	}
+	tok.SourceRange.File = nil
+	if tok != want {
+		t.Errorf("token mismatch, want: %+v, got: %+v", want, tok)
+	}
+}
+
+func BenchmarkLex(b *testing.B) {
+	var text strings.Builder
+	text.WriteString("ScriptName Foo Extends ObjectReference Hidden\n{The documentation.}\n")
+	for i := range 500 {
+		fmt.Fprintf(&text, `
+; A line comment.
+Int Property Count%[1]d = 0x1F Auto
+Float fValue%[1]d = 1.25
+Function DoThing%[1]d(Actor akTarget, Bool abForce = False) Global
+	;/ A block
+	   comment. /;
+	If akTarget != None && !abForce || Count%[1]d >= 10
+		Debug.Trace("Doing the thing " + akTarget as String)
+		fValue%[1]d += 2.5 * (Count%[1]d - 1) / 3
+	ElseIf self.IsDisabled()
+		Return
+	EndIf
+	While Count%[1]d < 100
+		Count%[1]d *= 2
+	EndWhile
+EndFunction
+`, i)
+	}
+	f := &source.File{Text: []byte(text.String())}
+	b.SetBytes(int64(len(f.Text)))
+	b.ResetTimer()
+	for range b.N {
+		l := lexer.New(f)
+		for {
+			tok, err := l.NextToken()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if tok.Type == token.EOF {
+				break
+			}
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	text := "ScriptName Foo ; comment\nx = a | b\xff\n\"bad \\q\" ;/ open"
+	type result struct {
+		Type    token.Type
+		Offset  int
+		Message string
+	}
+	want := []result{
+		{Type: token.ScriptName, Offset: 0},
+		{Type: token.Identifier, Offset: 11},
+		{Type: token.LineComment, Offset: 15},
+		{Type: token.Newline, Offset: 24},
+		{Type: token.Identifier, Offset: 25},
+		{Type: token.Assign, Offset: 27},
+		{Type: token.Identifier, Offset: 29},
+		{Type: token.Illegal, Offset: 31, Message: "'|' is not a valid operator"},
+		{Type: token.Identifier, Offset: 33},
+		{Type: token.Illegal, Offset: 34, Message: "encountered invalid UTF-8"},
+		{Type: token.Newline, Offset: 35},
+		{Type: token.Illegal, Offset: 36, Message: "encountered an invalid string escape sequence: \\q"},
+		{Type: token.BlockComment, Offset: 45, Message: "unterminated block comment, expected '/;' before end of file"},
+		{Type: token.EOF, Offset: 52},
+	}
+	l := lexer.New(&source.File{Text: []byte(text)})
+	var got []result
+	for tok, err := range l.Tokens() {
+		r := result{Type: tok.Type, Offset: tok.SourceRange.ByteOffset}
+		if err != nil {
+			r.Message = err.Error()
+		}
+		got = append(got, r)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Tokens() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTokensStop(t *testing.T) {
+	l := lexer.New(&source.File{Text: []byte("ScriptName Foo\nImport Bar\n")})
+	var got []token.Type
+	for tok := range l.Tokens() {
+		got = append(got, tok.Type)
+		if tok.Type == token.Newline {
+			break
+		}
+	}
+	want := []token.Type{token.ScriptName, token.Identifier, token.Newline}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Tokens() before break mismatch (-want +got):\n%s", diff)
+	}
+	// The iterator resumes where the previous loop stopped.
+	got = nil
+	for tok := range l.Tokens() {
+		got = append(got, tok.Type)
+	}
+	want = []token.Type{token.Import, token.Identifier, token.Newline, token.EOF}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Tokens() after break mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLineContinuation(t *testing.T) {
+	tests := []struct {
+		name              string
+		text              string
+		wantTypes         []token.Type
+		wantContinuations []source.Range
+	}{
+		{
+			name:      "newline",
+			text:      "a \\\nb",
+			wantTypes: []token.Type{token.Identifier, token.Identifier, token.EOF},
+			wantContinuations: []source.Range{
+				{ByteOffset: 2, Length: 2, Line: 1, Column: 3},
+			},
+		},
+		{
+			name:      "trailing_whitespace",
+			text:      "a \\ \t\nb",
+			wantTypes: []token.Type{token.Identifier, token.Identifier, token.EOF},
+			wantContinuations: []source.Range{
+				{ByteOffset: 2, Length: 4, Line: 1, Column: 3},
+			},
+		},
+		{
+			name:      "crlf",
+			text:      "a\\\r\nb\\\r\nc\n",
+			wantTypes: []token.Type{token.Identifier, token.Identifier, token.Identifier, token.Newline, token.EOF},
+			wantContinuations: []source.Range{
+				{ByteOffset: 1, Length: 3, Line: 1, Column: 2},
+				{ByteOffset: 5, Length: 3, Line: 2, Column: 2},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := lexer.New(&source.File{Text: []byte(test.text)})
+			var got []token.Type
+			for tok, err := range l.Tokens() {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				got = append(got, tok.Type)
+			}
+			if diff := cmp.Diff(test.wantTypes, got); diff != "" {
+				t.Errorf("token types mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.wantContinuations, l.Continuations(), cmpopts.IgnoreFields(source.Range{}, "File")); diff != "" {
+				t.Errorf("Continuations() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestInvalidLineContinuation(t *testing.T) {
+	l := lexer.New(&source.File{Text: []byte("a \\ b")})
+	if _, err := l.NextToken(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tok, err := l.NextToken()
+	if err == nil {
+		t.Fatalf("NextToken() returned no error, want one")
+	}
+	if want := "expected a newline after line continuation '\\'"; err.Error() != want {
+		t.Errorf("error mismatch, want: %q, got: %q", want, err.Error())
+	}
+	want := source.Range{ByteOffset: 2, Length: 1, Line: 1, Column: 3}
+	tok.SourceRange.File = nil
+	if tok.Type != token.Illegal || tok.SourceRange != want {
+		t.Errorf("token mismatch, want: Illegal at %+v, got: %s at %+v", want, tok.Type, tok.SourceRange)
+	}
+}
+
+func TestInvalidLineContinuationKeepsTokens(t *testing.T) {
+	l := lexer.New(&source.File{Text: []byte("a \\ b c\nd")})
+	var got []token.Type
+	for tok := range l.Tokens() {
+		got = append(got, tok.Type)
+	}
+	want := []token.Type{token.Identifier, token.Illegal, token.Identifier, token.Identifier, token.Newline, token.Identifier, token.EOF}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Tokens() mismatch (-want +got):\n%s", diff)
+	}
+	if got := l.Continuations(); len(got) != 0 {
+		t.Errorf("Continuations() = %v, want none", got)
+	}
+}
+
+func TestWindows1252(t *testing.T) {
+	f := source.NewFileFromBytes("", []byte("s = \"caf\xE9\" + x"), source.Windows1252)
+	l := lexer.New(f)
+	var got []token.Token
+	for tok, err := range l.Tokens() {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got = append(got, tok)
+	}
+	want := []token.Token{
+		{Type: token.Identifier, SourceRange: source.Range{ByteOffset: 0, Length: 1, Line: 1, Column: 1}},
+		{Type: token.Assign, SourceRange: source.Range{ByteOffset: 2, Length: 1, Line: 1, Column: 3}},
+		{Type: token.StringLiteral, SourceRange: source.Range{ByteOffset: 4, Length: 7, Line: 1, Column: 5}},
+		{Type: token.Add, SourceRange: source.Range{ByteOffset: 12, Length: 1, Line: 1, Column: 12}},
+		{Type: token.Identifier, SourceRange: source.Range{ByteOffset: 14, Length: 1, Line: 1, Column: 14}},
+		{Type: token.EOF, SourceRange: source.Range{ByteOffset: 15, Length: 0, Line: 1, Column: 15}},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(source.Range{}, "File")); diff != "" {
+		t.Errorf("Tokens() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNumberLiterals(t *testing.T) {
+	tests := []struct {
+		text string
+		want token.Type
+	}{
+		{"0", token.IntLiteral},
+		{"42", token.IntLiteral},
+		{"0x1F", token.IntLiteral},
+		{"0X1f", token.IntLiteral},
+		{"0xabcdef", token.IntLiteral},
+		{"0xFFFFFFFF", token.IntLiteral},
+		{"0x000000001", token.IntLiteral},
+		{"1.25", token.FloatLiteral},
+		{"1e5", token.FloatLiteral},
+		{"1E5", token.FloatLiteral},
+		{"2.5e-3", token.FloatLiteral},
+		{"2.5E+3", token.FloatLiteral},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			l := lexer.New(&source.File{Text: []byte(test.text + " x")})
+			got, err := l.NextToken()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := token.Token{
+				Type:        test.want,
+				SourceRange: source.Range{Length: len(test.text), Line: 1, Column: 1},
+			}
+			if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(source.Range{}, "File")); diff != "" {
+				t.Errorf("NextToken() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestInvalidNumberLiterals(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"0x", "expected a digit to follow the x in a hex int literal"},
+		{"0X", "expected a digit to follow the X in a hex int literal"},
+		{"0xFFFFFFFFFF", "hex int literal 0xFFFFFFFFFF does not fit in 32 bits"},
+		{"0X123456789", "hex int literal 0X123456789 does not fit in 32 bits"},
+		{"1.", "expected a digit to follow the dot in a float literal"},
+		{"1e", "expected a digit in the exponent of a float literal"},
+		{"2.5E-", "expected a digit in the exponent of a float literal"},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			l := lexer.New(&source.File{Text: []byte(test.text)})
+			tok, err := l.NextToken()
+			if err == nil {
+				t.Fatalf("NextToken() returned no error, want %q", test.want)
+			}
+			if err.Error() != test.want {
+				t.Errorf("NextToken() returned error %q, want %q", err, test.want)
+			}
+			if tok.Type != token.Illegal {
+				t.Errorf("NextToken() returned a %s token, want %s", tok.Type, token.Illegal)
+			}
+		})
+	}
+}
+
+func TestStringLiterals(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []token.Type
+		length  int
+		message string
+	}{
+		{
+			name:   "simple",
+			text:   `"abc" x`,
+			want:   []token.Type{token.StringLiteral, token.Identifier, token.EOF},
+			length: 5,
+		},
+		{
+			name:   "empty",
+			text:   `"" x`,
+			want:   []token.Type{token.StringLiteral, token.Identifier, token.EOF},
+			length: 2,
+		},
+		{
+			name:   "escapes",
+			text:   `"\n\t\"\\" x`,
+			want:   []token.Type{token.StringLiteral, token.Identifier, token.EOF},
+			length: 10,
+		},
+		{
+			name:    "invalid_escape",
+			text:    `"ab\qcd" y`,
+			want:    []token.Type{token.Illegal, token.Identifier, token.EOF},
+			length:  8,
+			message: "encountered an invalid string escape sequence: \\q",
+		},
+		{
+			name:    "invalid_escape_then_escaped_quote",
+			text:    `"a\q\"b" y`,
+			want:    []token.Type{token.Illegal, token.Identifier, token.EOF},
+			length:  8,
+			message: "encountered an invalid string escape sequence: \\q",
+		},
+		{
+			name:    "invalid_escape_unterminated",
+			text:    "\"a\\q b\ny",
+			want:    []token.Type{token.Illegal, token.Newline, token.Identifier, token.EOF},
+			length:  6,
+			message: "encountered an invalid string escape sequence: \\q",
+		},
+		{
+			name:    "unterminated",
+			text:    `"abc`,
+			want:    []token.Type{token.Illegal, token.EOF},
+			length:  4,
+			message: "reached end of file while reading string literal",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := lexer.New(&source.File{Text: []byte(test.text)})
+			var got []token.Type
+			for tok, err := range l.Tokens() {
+				if len(got) == 0 {
+					if tok.SourceRange.Length != test.length {
+						t.Errorf("first token length = %d, want %d", tok.SourceRange.Length, test.length)
+					}
+					message := ""
+					if err != nil {
+						message = err.Error()
+					}
+					if message != test.message {
+						t.Errorf("first token error = %q, want %q", message, test.message)
+					}
+				} else if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				got = append(got, tok.Type)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Tokens() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPositionsMatchLineIndex(t *testing.T) {
+	text := "\xEF\xBB\xBFScriptName Foo\r\n\t\tInt\tx = 1 ; café\n\t{doc\n\tcomment}\r\tFloat y \\\n\t\t= \"日本\"\t+ z\n"
+	f := source.NewFile("", []byte(text))
+	lines := f.Lines()
+	for tok, err := range lexer.New(f).Tokens() {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		line, column := lines.Position(tok.SourceRange.ByteOffset)
+		if tok.SourceRange.Line != line || tok.SourceRange.Column != column {
+			t.Errorf("%s at offset %d is at %d:%d, LineIndex reports %d:%d", tok.Type, tok.SourceRange.ByteOffset, tok.SourceRange.Line, tok.SourceRange.Column, line, column)
+		}
+	}
+}
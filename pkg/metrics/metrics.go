@@ -0,0 +1,113 @@
+// Package metrics provides lightweight instrumentation for tracking the
+// performance of Papyrus tooling.
+package metrics
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Metrics holds the measurements taken while processing a single file (or the
+// aggregate of several files).
+type Metrics struct {
+	// Path is the path of the file measured or empty for aggregates.
+	Path string
+	// Files is the number of files measured.
+	Files int
+	// Lex is the wall time spent lexing.
+	Lex time.Duration
+	// Parse is the wall time spent parsing, excluding time spent lexing.
+	Parse time.Duration
+	// Bytes is the number of bytes of source text processed.
+	Bytes int
+	// Tokens is the number of tokens produced by the lexer.
+	Tokens int
+	// Nodes is the number of AST nodes produced by the parser.
+	Nodes int
+	// Allocated is the approximate total number of bytes allocated on the heap
+	// while processing, including memory that has since been freed. It is not
+	// the peak heap use.
+	Allocated uint64
+}
+
+// Total returns the total wall time across all phases.
+func (m Metrics) Total() time.Duration {
+	return m.Lex + m.Parse
+}
+
+// String returns the metrics as a one-line summary.
+func (m Metrics) String() string {
+	name := m.Path
+	if name == "" {
+		name = fmt.Sprintf("%d files", m.Files)
+	}
+	return fmt.Sprintf("%s: total=%v lex=%v parse=%v bytes=%d tokens=%d nodes=%d alloc=%d", name, m.Total(), m.Lex, m.Parse, m.Bytes, m.Tokens, m.Nodes, m.Allocated)
+}
+
+func (m *Metrics) add(o Metrics) {
+	m.Files += o.Files
+	m.Lex += o.Lex
+	m.Parse += o.Parse
+	m.Bytes += o.Bytes
+	m.Tokens += o.Tokens
+	m.Nodes += o.Nodes
+	m.Allocated += o.Allocated
+}
+
+// Collector accumulates [Metrics] for one or more files.
+//
+// A nil *Collector is valid and discards everything recorded, but callers in
+// performance sensitive code should check for nil before taking measurements
+// at all.
+//
+// A Collector is safe for concurrent use.
+type Collector struct {
+	mu    sync.Mutex
+	files []Metrics
+}
+
+// NewCollector returns a new, empty [*Collector].
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+// Record adds the metrics for a single file to the collector.
+func (c *Collector) Record(m Metrics) {
+	if c == nil {
+		return
+	}
+	if m.Files == 0 {
+		m.Files = 1
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.files = append(c.files, m)
+}
+
+// Files returns the metrics recorded for each file in the order they were
+// recorded.
+func (c *Collector) Files() []Metrics {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	files := make([]Metrics, len(c.files))
+	copy(files, c.files)
+	return files
+}
+
+// Aggregate returns the sum of the metrics recorded for all files.
+func (c *Collector) Aggregate() Metrics {
+	var total Metrics
+	if c == nil {
+		return total
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, m := range c.files {
+		total.add(m)
+	}
+	return total
+}
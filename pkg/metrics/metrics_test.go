@@ -0,0 +1,59 @@
+package metrics_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TLBuf/papyrus/pkg/metrics"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAggregate(t *testing.T) {
+	c := metrics.NewCollector()
+	c.Record(metrics.Metrics{Path: "a.psc", Lex: time.Millisecond, Parse: 2 * time.Millisecond, Bytes: 10, Tokens: 3, Nodes: 2, Allocated: 100})
+	c.Record(metrics.Metrics{Path: "b.psc", Lex: time.Millisecond, Parse: time.Millisecond, Bytes: 5, Tokens: 1, Nodes: 1, Allocated: 50})
+	want := metrics.Metrics{Files: 2, Lex: 2 * time.Millisecond, Parse: 3 * time.Millisecond, Bytes: 15, Tokens: 4, Nodes: 3, Allocated: 150}
+	if diff := cmp.Diff(want, c.Aggregate()); diff != "" {
+		t.Errorf("Aggregate() mismatch (-want +got):\n%s", diff)
+	}
+	if got := len(c.Files()); got != 2 {
+		t.Errorf("Files() returned %d entries, want 2", got)
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics metrics.Metrics
+		want    string
+	}{
+		{
+			name:    "file",
+			metrics: metrics.Metrics{Path: "a.psc", Files: 1, Lex: time.Millisecond, Parse: time.Millisecond, Bytes: 10, Tokens: 3, Nodes: 2, Allocated: 100},
+			want:    "a.psc: total=2ms lex=1ms parse=1ms bytes=10 tokens=3 nodes=2 alloc=100",
+		},
+		{
+			name:    "aggregate",
+			metrics: metrics.Metrics{Files: 2},
+			want:    "2 files: total=0s lex=0s parse=0s bytes=0 tokens=0 nodes=0 alloc=0",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.metrics.String(); got != test.want {
+				t.Errorf("String() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNilCollector(t *testing.T) {
+	var c *metrics.Collector
+	c.Record(metrics.Metrics{Bytes: 1})
+	if got := c.Files(); got != nil {
+		t.Errorf("Files() = %v, want nil", got)
+	}
+	if diff := cmp.Diff(metrics.Metrics{}, c.Aggregate()); diff != "" {
+		t.Errorf("Aggregate() mismatch (-want +got):\n%s", diff)
+	}
+}
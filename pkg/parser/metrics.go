@@ -0,0 +1,32 @@
+package parser
+
+import (
+	rtmetrics "runtime/metrics"
+
+	"github.com/TLBuf/papyrus/pkg/ast"
+)
+
+// countNodes returns the number of nodes in the given script, including the
+// script itself.
+func countNodes(script *ast.Script) int {
+	if script == nil {
+		return 0
+	}
+	n := 0
+	ast.Inspect(script, func(ast.Node) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// heapAllocated returns the cumulative number of bytes allocated on the heap.
+//
+// Unlike [runtime.ReadMemStats], reading this does not stop the world, so it
+// does not disturb the timings taken around it. Small allocations are counted
+// as their per-processor caches are refilled, so the value is approximate.
+func heapAllocated() uint64 {
+	sample := []rtmetrics.Sample{{Name: "/gc/heap/allocs:bytes"}}
+	rtmetrics.Read(sample)
+	return sample[0].Value.Uint64()
+}
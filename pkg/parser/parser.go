@@ -4,10 +4,13 @@ package parser
 import (
 	"bytes"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/TLBuf/papyrus/pkg/ast"
 	"github.com/TLBuf/papyrus/pkg/lexer"
+	"github.com/TLBuf/papyrus/pkg/metrics"
 	"github.com/TLBuf/papyrus/pkg/source"
 	"github.com/TLBuf/papyrus/pkg/token"
 )
@@ -16,6 +19,8 @@ import (
 // [*ast.Script].
 type Parser struct {
 	keepLooseComments bool
+	metrics           *metrics.Collector
+	warnings          func(Error)
 }
 
 type Option func(*Parser)
@@ -29,6 +34,23 @@ func WithLooseComments(keep bool) Option {
 	}
 }
 
+// WithMetrics directs the parser to record timing and memory measurements for
+// each file parsed into the given collector. Passing nil disables measurement.
+func WithMetrics(collector *metrics.Collector) Option {
+	return func(p *Parser) {
+		p.metrics = collector
+	}
+}
+
+// WithWarnings directs the parser to report non-fatal issues found in each file
+// (e.g. a lone carriage return used as a line break or text before the script
+// header) to the given handler in source order. Passing nil discards them.
+func WithWarnings(handler func(Error)) Option {
+	return func(p *Parser) {
+		p.warnings = handler
+	}
+}
+
 // New returns a [*Parser] that is configured to parser script files.
 func New(opts ...Option) *Parser {
 	p := &Parser{}
@@ -40,18 +62,44 @@ func New(opts ...Option) *Parser {
 
 // Parser returns the file parsed as an [*ast.Script] or an [Error] if parsing
 // encountered one or more issues.
+//
+// If the lexer was able to recover from an error (e.g. a comment that was not
+// terminated before the end of the file), the script is returned along with
+// that error.
 func (p *Parser) Parse(file *source.File) (*ast.Script, error) {
 	prsr := &parser{
 		l:                 lexer.New(file),
 		keepLooseComments: p.keepLooseComments,
-	}
-	if err := prsr.next(); err != nil {
-		return nil, err
-	}
-	if err := prsr.next(); err != nil {
-		return nil, err
-	}
-	return prsr.ParseScript()
+		preamble:          true,
+	}
+	if p.warnings != nil {
+		defer prsr.reportWarnings(p.warnings)
+	}
+	if p.metrics == nil {
+		return prsr.parse()
+	}
+	allocated := heapAllocated()
+	start := time.Now()
+	// Lex the whole file up front so lexing is timed once rather than per token.
+	// Tokens average several bytes each, so this capacity avoids most regrowth.
+	prsr.lexed = make([]lexed, 0, len(file.Text)/4+1)
+	for t, err := range prsr.l.Tokens() {
+		prsr.lexed = append(prsr.lexed, lexed{t, err})
+	}
+	lexTime := time.Since(start)
+	tokens := len(prsr.lexed)
+	script, err := prsr.parse()
+	parseTime := time.Since(start) - lexTime
+	p.metrics.Record(metrics.Metrics{
+		Path:      file.Path,
+		Lex:       lexTime,
+		Parse:     parseTime,
+		Bytes:     len(file.Text),
+		Tokens:    tokens,
+		Nodes:     countNodes(script),
+		Allocated: heapAllocated() - allocated,
+	})
+	return script, err
 }
 
 type parser struct {
@@ -63,17 +111,91 @@ type parser struct {
 	keepLooseComments bool
 	looseComments     []token.Token
 
+	// preamble is true until the ScriptName keyword is reached. Text before it
+	// is skipped, so text the lexer cannot read there is skipped too.
+	preamble bool
+	// illegal is the lexer error for an Illegal lookahead token read during the
+	// preamble.
+	illegal Error
+
 	recovery bool
 	errors   []ast.Error
+	// lexErrors are errors the lexer recovered from, reported once parsing
+	// completes.
+	lexErrors []Error
+	warnings  []Error
+
+	// lexed holds the tokens of the file, lexed up front when taking
+	// measurements, and is consumed by next; it is nil otherwise.
+	lexed []lexed
+}
+
+// lexed is a token and the error the lexer returned for it.
+type lexed struct {
+	token token.Token
+	err   error
+}
+
+// reportWarnings passes the lexer and parser warnings to handler in source
+// order.
+func (p *parser) reportWarnings(handler func(Error)) {
+	warnings := p.warnings
+	for _, w := range p.l.Warnings() {
+		warnings = append(warnings, newError(w.Location, "%s", w.Message))
+	}
+	slices.SortStableFunc(warnings, func(a, b Error) int {
+		return a.Location.ByteOffset - b.Location.ByteOffset
+	})
+	for _, w := range warnings {
+		handler(w)
+	}
+}
+
+func (p *parser) parse() (*ast.Script, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	script, err := p.ParseScript()
+	if len(p.lexErrors) > 0 {
+		// Recoverable lexer errors (i.e. unterminated comments) always extend to
+		// the end of the file, so any parser error is a consequence of them.
+		return script, p.lexErrors[0]
+	}
+	return script, err
 }
 
 // next advances token and lookahead by one token while skipping loose comment
 // tokens. Returns true if parsing should continue, false otherwise.
 func (p *parser) next() error {
 	p.token = p.lookahead
-	t, err := p.l.NextToken()
+	var t token.Token
+	var err error
+	if p.lexed != nil {
+		t, err = p.lexed[0].token, p.lexed[0].err
+		// Keep the final EOF token so reading past the end yields EOF again, as
+		// the lexer does.
+		if len(p.lexed) > 1 {
+			p.lexed = p.lexed[1:]
+		}
+	} else {
+		t, err = p.l.NextToken()
+	}
 	if err != nil {
-		return newError(err.(lexer.Error).Location, err.(lexer.Error).Message)
+		lexErr := err.(lexer.Error)
+		switch {
+		case t.Type != token.Illegal:
+			// The lexer recovered and produced a usable token, so keep parsing.
+			p.lexErrors = append(p.lexErrors, newError(lexErr.Location, lexErr.Message))
+		case p.preamble:
+			// The token will be skipped along with the rest of the preamble, so
+			// the error only matters if it turns out to follow ScriptName.
+			p.illegal = newError(lexErr.Location, lexErr.Message)
+		default:
+			return newError(lexErr.Location, lexErr.Message)
+		}
 	}
 	p.lookahead = t
 	// Consume loose comments immediately so the rest of the
@@ -87,6 +209,25 @@ func (p *parser) next() error {
 	return nil
 }
 
+// takeLooseComments returns the loose comments retained so far as nodes and
+// clears them.
+func (p *parser) takeLooseComments() []ast.LooseComment {
+	if len(p.looseComments) == 0 {
+		return nil
+	}
+	comments := make([]ast.LooseComment, len(p.looseComments))
+	for i, t := range p.looseComments {
+		text := string(t.SourceRange.Text())
+		if t.Type == token.LineComment {
+			comments[i] = &ast.LineComment{Text: text, SourceRange: t.SourceRange}
+		} else {
+			comments[i] = &ast.BlockComment{Text: text, SourceRange: t.SourceRange}
+		}
+	}
+	p.looseComments = nil
+	return comments
+}
+
 // tryConsume advances the token position if the current token matches the given
 // token type or returns an error.
 func (p *parser) tryConsume(t token.Type, alts ...token.Type) error {
@@ -128,17 +269,16 @@ func (p *parser) ParseScript() (*ast.Script, error) {
 			Column: 1,
 		},
 	}
+	if err := p.skipToHeader(); err != nil {
+		return nil, err
+	}
+	script.HeaderComments = p.takeLooseComments()
 	if err := p.ParseScriptHeader(script); err != nil {
 		return nil, err
 	}
-	if p.token.Type == token.DocComment {
-		script.Comment = &ast.DocComment{
-			Text:        string(p.token.SourceRange.Text()),
-			SourceRange: p.token.SourceRange,
-		}
-		if err := p.next(); err != nil {
-			return nil, err
-		}
+	var err error
+	if script.Comment, err = p.ParseDocComment(); err != nil {
+		return nil, err
 	}
 	for p.token.Type != token.EOF {
 		if err := p.consumeNewlines(); err != nil {
@@ -155,6 +295,65 @@ func (p *parser) ParseScript() (*ast.Script, error) {
 	return script, nil
 }
 
+// ParseDocComment parses the documentation comment that follows a declaration,
+// if there is one, skipping any blank lines (and loose comments) before it.
+//
+// Returns nil if the next token after any newlines is not a doc comment.
+func (p *parser) ParseDocComment() (*ast.DocComment, error) {
+	if err := p.consumeNewlines(); err != nil {
+		return nil, err
+	}
+	if p.token.Type != token.DocComment {
+		return nil, nil
+	}
+	comment := &ast.DocComment{
+		Text:        string(p.token.SourceRange.Text()),
+		SourceRange: p.token.SourceRange,
+	}
+	return comment, p.next()
+}
+
+// skipToHeader advances to the ScriptName keyword, skipping (with a warning)
+// anything other than newlines and comments that appears before it, including
+// text the lexer cannot read.
+//
+// A documentation comment before ScriptName documents nothing, so it is kept
+// with the other header comments as a block comment.
+func (p *parser) skipToHeader() error {
+	var start, end token.Token
+	skipped := false
+	for p.token.Type != token.ScriptName {
+		switch p.token.Type {
+		case token.EOF:
+			if !skipped {
+				return nil
+			}
+			return newError(start.SourceRange, "expected %s, but found %s", token.ScriptName, start.Type)
+		case token.Newline:
+		case token.DocComment:
+			if p.keepLooseComments {
+				p.looseComments = append(p.looseComments, p.token)
+			}
+		default:
+			if !skipped {
+				start, skipped = p.token, true
+			}
+			end = p.token
+		}
+		if err := p.next(); err != nil {
+			return err
+		}
+	}
+	p.preamble = false
+	if p.lookahead.Type == token.Illegal {
+		return p.illegal
+	}
+	if skipped {
+		p.warnings = append(p.warnings, newError(source.Span(start.SourceRange, end.SourceRange), "ignored unexpected text before %s", token.ScriptName))
+	}
+	return nil
+}
+
 func (p *parser) ParseScriptHeader(script *ast.Script) error {
 	if err := p.tryConsume(token.ScriptName); err != nil {
 		return err
@@ -404,6 +603,9 @@ func (p *parser) ParseScriptVariable(variableType *ast.TypeLiteral) (*ast.Script
 
 func (p *parser) ParseIdentifier() (*ast.Identifier, error) {
 	rng := p.token.SourceRange
+	if p.token.Type.IsKeyword() {
+		return nil, newError(rng, "'%s' is a reserved word and cannot be used as a name", rng.Text())
+	}
 	if err := p.tryConsume(token.Identifier); err != nil {
 		return nil, err
 	}
@@ -1,9 +1,14 @@
 package parser_test
 
 import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/TLBuf/papyrus/pkg/ast"
+	"github.com/TLBuf/papyrus/pkg/metrics"
 	"github.com/TLBuf/papyrus/pkg/parser"
 	"github.com/TLBuf/papyrus/pkg/source"
 	"github.com/google/go-cmp/cmp"
@@ -241,7 +246,7 @@ func TestHeader(t *testing.T) {
 							ByteOffset: 18,
 							Length:     10,
 							Line:       2,
-							Column:     2,
+							Column:     4,
 						},
 					},
 				},
@@ -284,7 +289,7 @@ func TestHeader(t *testing.T) {
 							ByteOffset: 18,
 							Length:     21,
 							Line:       2,
-							Column:     2,
+							Column:     4,
 						},
 					},
 				},
@@ -327,7 +332,7 @@ func TestHeader(t *testing.T) {
 							ByteOffset: 18,
 							Length:     26,
 							Line:       2,
-							Column:     2,
+							Column:     4,
 						},
 					},
 				},
@@ -360,3 +365,558 @@ func TestHeader(t *testing.T) {
 	}
 
 }
+
+func TestMetrics(t *testing.T) {
+	input := `ScriptName Foo Extends Bar
+Import Baz
+State Qux
+EndState
+`
+	collector := metrics.NewCollector()
+	f := &source.File{Path: "foo.psc", Text: []byte(input)}
+	p := parser.New(parser.WithMetrics(collector))
+	if _, err := p.Parse(f); err != nil {
+		t.Fatalf("Parse() returned an unexpected error: %v", err)
+	}
+	files := collector.Files()
+	if len(files) != 1 {
+		t.Fatalf("Files() returned %d entries, want 1", len(files))
+	}
+	got := files[0]
+	if got.Path != "foo.psc" {
+		t.Errorf("Path = %q, want %q", got.Path, "foo.psc")
+	}
+	if got.Bytes != len(input) {
+		t.Errorf("Bytes = %d, want %d", got.Bytes, len(input))
+	}
+	// ScriptName, Foo, Extends, Bar, four newlines, Import, Baz, State, Qux,
+	// EndState, EOF.
+	if got.Tokens != 14 {
+		t.Errorf("Tokens = %d, want 14", got.Tokens)
+	}
+	// Script, Foo, Bar, Import, Baz, State, Qux.
+	if got.Nodes != 7 {
+		t.Errorf("Nodes = %d, want 7", got.Nodes)
+	}
+	if got.Total() <= 0 {
+		t.Errorf("Total() = %v, want > 0", got.Total())
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	var text strings.Builder
+	text.WriteString("ScriptName Foo Extends Bar\n")
+	for i := range 1000 {
+		fmt.Fprintf(&text, "Import Baz%d\nState Qux%d\nEndState\n", i, i)
+	}
+	f := &source.File{Text: []byte(text.String())}
+	b.Run("nil_collector", func(b *testing.B) {
+		p := parser.New()
+		for range b.N {
+			if _, err := p.Parse(f); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("collector", func(b *testing.B) {
+		p := parser.New(parser.WithMetrics(metrics.NewCollector()))
+		for range b.N {
+			if _, err := p.Parse(f); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestUnterminatedComment(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantComment bool
+		wantMessage string
+	}{
+		{
+			name:        "doc_comment_header",
+			input:       "ScriptName Foo\n{A doc\ncomment",
+			wantComment: true,
+			wantMessage: "unterminated doc comment, expected '}' before end of file",
+		},
+		{
+			name:        "block_comment",
+			input:       "ScriptName Foo\nImport Bar\n;/ A\nblock",
+			wantMessage: "unterminated block comment, expected '/;' before end of file",
+		},
+		{
+			name:        "single_brace",
+			input:       "{",
+			wantMessage: "unterminated doc comment, expected '}' before end of file",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := &source.File{Text: []byte(test.input)}
+			got, err := parser.New().Parse(f)
+			if err == nil {
+				t.Fatalf("Parse() returned no error, want %q", test.wantMessage)
+			}
+			if err.Error() != test.wantMessage {
+				t.Errorf("Parse() returned error %q, want %q", err.Error(), test.wantMessage)
+			}
+			if !test.wantComment {
+				return
+			}
+			if got == nil {
+				t.Fatalf("Parse() returned nil script")
+			}
+			if got.Comment == nil {
+				t.Fatalf("Parse() returned script with nil Comment")
+			}
+			if want := "{A doc\ncomment"; got.Comment.Text != want {
+				t.Errorf("Comment.Text = %q, want %q", got.Comment.Text, want)
+			}
+		})
+	}
+}
+
+func TestReservedWordName(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "script_name",
+			input: "ScriptName New",
+			want:  "'New' is a reserved word and cannot be used as a name",
+		},
+		{
+			name:  "extends",
+			input: "ScriptName Foo Extends state",
+			want:  "'state' is a reserved word and cannot be used as a name",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := &source.File{Text: []byte(test.input)}
+			_, err := parser.New().Parse(f)
+			if err == nil {
+				t.Fatalf("Parse() returned no error, want %q", test.want)
+			}
+			if err.Error() != test.want {
+				t.Errorf("Parse() returned error %q, want %q", err.Error(), test.want)
+			}
+		})
+	}
+}
+
+func TestReservedWordNameRecovery(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name: "import",
+			input: `ScriptName Foo
+Import Self`,
+			want: "'Self' is a reserved word and cannot be used as a name",
+		},
+		{
+			name: "state",
+			input: `ScriptName Foo
+State RETURN
+EndState`,
+			want: "'RETURN' is a reserved word and cannot be used as a name",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := &source.File{Text: []byte(test.input)}
+			got, err := parser.New().Parse(f)
+			if err != nil {
+				t.Fatalf("Parse() returned an unexpected error: %v", err)
+			}
+			if len(got.Statements) == 0 {
+				t.Fatalf("Parse() returned no statements")
+			}
+			errStmt, ok := got.Statements[0].(*ast.ErrorScriptStatement)
+			if !ok {
+				t.Fatalf("Parse() returned %T as the first statement, want *ast.ErrorScriptStatement", got.Statements[0])
+			}
+			if errStmt.Message != test.want {
+				t.Errorf("ErrorScriptStatement.Message = %q, want %q", errStmt.Message, test.want)
+			}
+		})
+	}
+}
+
+func TestHeaderComments(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		keep  bool
+		want  []ast.LooseComment
+	}{
+		{
+			name:  "directive",
+			input: ";#requires SKSE 2.0.20\nScriptName Foo",
+			keep:  true,
+			want: []ast.LooseComment{
+				&ast.LineComment{
+					Text:        ";#requires SKSE 2.0.20",
+					SourceRange: source.Range{ByteOffset: 0, Length: 22, Line: 1, Column: 1},
+				},
+			},
+		},
+		{
+			name:  "multiple_with_blank_lines",
+			input: "\n;  Generated file\n\n;/ Do not\n   edit /;\r\n\nScriptName Foo\n; Not a header comment\n",
+			keep:  true,
+			want: []ast.LooseComment{
+				&ast.LineComment{
+					Text:        ";  Generated file",
+					SourceRange: source.Range{ByteOffset: 1, Length: 17, Line: 2, Column: 1},
+				},
+				&ast.BlockComment{
+					Text:        ";/ Do not\n   edit /;",
+					SourceRange: source.Range{ByteOffset: 20, Length: 20, Line: 4, Column: 1},
+				},
+			},
+		},
+		{
+			name:  "byte_order_mark",
+			input: "\xEF\xBB\xBF; Header\nScriptName Foo",
+			keep:  true,
+			want: []ast.LooseComment{
+				&ast.LineComment{
+					Text:        "; Header",
+					SourceRange: source.Range{ByteOffset: 3, Length: 8, Line: 1, Column: 1},
+				},
+			},
+		},
+		{
+			name:  "doc_comment_before_header",
+			input: "{Fragment}\nScriptName Foo\n{The script.}",
+			keep:  true,
+			want: []ast.LooseComment{
+				&ast.BlockComment{
+					Text:        "{Fragment}",
+					SourceRange: source.Range{ByteOffset: 0, Length: 10, Line: 1, Column: 1},
+				},
+			},
+		},
+		{
+			name:  "not_retained",
+			input: "; Header\nScriptName Foo",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := &source.File{Text: []byte(test.input)}
+			got, err := parser.New(parser.WithLooseComments(test.keep)).Parse(f)
+			if err != nil {
+				t.Fatalf("Parse() returned an unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.want, got.HeaderComments, cmpopts.IgnoreFields(source.Range{}, "File")); diff != "" {
+				t.Errorf("HeaderComments mismatch (-want +got):\n%s", diff)
+			}
+			if got.Name == nil || got.Name.Text != "foo" {
+				t.Errorf("Name = %v, want foo", got.Name)
+			}
+		})
+	}
+}
+
+func TestStatementOrderIndependentOfComments(t *testing.T) {
+	input := `; Header comment
+;/ Header
+   block /;
+
+ScriptName Foo Extends Bar ; Trailing header comment
+{Script documentation}
+; Between documentation and imports
+Import Baz ; Trailing import comment
+;/ Between imports /;
+Import Qux
+
+; Before a state
+Auto State Waiting ; Trailing state comment
+	; Inside a state
+EndState
+`
+	var scripts []*ast.Script
+	for _, keep := range []bool{false, true} {
+		f := &source.File{Text: []byte(input)}
+		script, err := parser.New(parser.WithLooseComments(keep)).Parse(f)
+		if err != nil {
+			t.Fatalf("Parse() with loose comments %t returned an unexpected error: %v", keep, err)
+		}
+		scripts = append(scripts, script)
+	}
+	without, with := scripts[0], scripts[1]
+	if len(with.HeaderComments) != 2 {
+		t.Errorf("HeaderComments has %d comments, want 2", len(with.HeaderComments))
+	}
+	if with.Comment == nil || with.Comment.Text != "{Script documentation}" {
+		t.Errorf("Comment = %v, want the script documentation", with.Comment)
+	}
+	// Other than header comments, both parses must produce the same tree.
+	with.HeaderComments = nil
+	if diff := cmp.Diff(without, with, cmpopts.IgnoreFields(source.Range{}, "File")); diff != "" {
+		t.Errorf("Parse() results differ with loose comments (-without +with):\n%s", diff)
+	}
+	var offsets []int
+	for _, stmt := range with.Statements {
+		offsets = append(offsets, stmt.Range().ByteOffset)
+	}
+	if !slices.IsSorted(offsets) || len(offsets) != 3 {
+		t.Errorf("statement offsets = %v, want 3 statements in source order", offsets)
+	}
+}
+
+func TestLineContinuation(t *testing.T) {
+	continued := "ScriptName Foo \\\n\tExtends Bar \\\r\n\tHidden \\\n\tConditional\nImport \\\n Baz\n"
+	single := "ScriptName Foo Extends Bar Hidden Conditional\nImport Baz\n"
+	var scripts []*ast.Script
+	for _, text := range []string{continued, single} {
+		script, err := parser.New().Parse(&source.File{Text: []byte(text)})
+		if err != nil {
+			t.Fatalf("Parse(%q) returned an unexpected error: %v", text, err)
+		}
+		scripts = append(scripts, script)
+	}
+	// Locations differ, but the trees must otherwise be identical.
+	if diff := cmp.Diff(scripts[1], scripts[0], cmpopts.IgnoreTypes(source.Range{})); diff != "" {
+		t.Errorf("Parse() results differ (-single +continued):\n%s", diff)
+	}
+}
+
+func TestTextBeforeHeader(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantWarnings []parser.Error
+		wantComments int
+	}{
+		{
+			name:  "stray_identifier",
+			input: "stray\nScriptName Foo\n",
+			wantWarnings: []parser.Error{
+				{
+					Message:  "ignored unexpected text before ScriptName",
+					Location: source.Range{ByteOffset: 0, Length: 5, Line: 1, Column: 1},
+				},
+			},
+		},
+		{
+			name:  "fragment_marker",
+			input: ";BEGIN FRAGMENT Fragment_0\n\nfoo bar\n; baz\nqux\n\nScriptName Foo\n",
+			wantWarnings: []parser.Error{
+				{
+					Message:  "ignored unexpected text before ScriptName",
+					Location: source.Range{ByteOffset: 28, Length: 17, Line: 3, Column: 1},
+				},
+			},
+			wantComments: 2,
+		},
+		{
+			name:  "unlexable_character",
+			input: "garbage @ here\nScriptName Foo\n",
+			wantWarnings: []parser.Error{
+				{
+					Message:  "ignored unexpected text before ScriptName",
+					Location: source.Range{ByteOffset: 0, Length: 14, Line: 1, Column: 1},
+				},
+			},
+		},
+		{
+			name:  "invalid_operator",
+			input: "a | b\nScriptName Foo\n",
+			wantWarnings: []parser.Error{
+				{
+					Message:  "ignored unexpected text before ScriptName",
+					Location: source.Range{ByteOffset: 0, Length: 5, Line: 1, Column: 1},
+				},
+			},
+		},
+		{
+			name:         "comments_only",
+			input:        "; Header\n\nScriptName Foo\n",
+			wantComments: 1,
+		},
+		{
+			name:         "doc_comment",
+			input:        "{Exported by the Creation Kit}\n; Header\nScriptName Foo\n",
+			wantComments: 2,
+		},
+		{
+			name:  "lone_carriage_return",
+			input: "ScriptName Foo\rImport Bar\n",
+			wantWarnings: []parser.Error{
+				{
+					Message:  "carriage return is not followed by a newline, treating it as a line break",
+					Location: source.Range{ByteOffset: 14, Length: 1, Line: 1, Column: 15},
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var warnings []parser.Error
+			p := parser.New(parser.WithLooseComments(true), parser.WithWarnings(func(w parser.Error) {
+				warnings = append(warnings, w)
+			}))
+			got, err := p.Parse(&source.File{Text: []byte(test.input)})
+			if err != nil {
+				t.Fatalf("Parse() returned an unexpected error: %v", err)
+			}
+			if got.Name == nil || got.Name.Text != "foo" {
+				t.Errorf("Name = %v, want foo", got.Name)
+			}
+			if len(got.HeaderComments) != test.wantComments {
+				t.Errorf("HeaderComments has %d comments, want %d", len(got.HeaderComments), test.wantComments)
+			}
+			if diff := cmp.Diff(test.wantWarnings, warnings, cmpopts.IgnoreFields(source.Range{}, "File")); diff != "" {
+				t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnlexableTextAfterHeader(t *testing.T) {
+	_, err := parser.New().Parse(&source.File{Text: []byte("stray @\nScriptName @\n")})
+	if err == nil {
+		t.Fatalf("Parse() returned no error")
+	}
+	if want := "failed to lex any token"; err.Error() != want {
+		t.Errorf("Parse() returned error %q, want %q", err.Error(), want)
+	}
+}
+
+func TestMissingHeader(t *testing.T) {
+	_, err := parser.New().Parse(&source.File{Text: []byte("Import Foo\n")})
+	if err == nil {
+		t.Fatalf("Parse() returned no error")
+	}
+	if want := "expected ScriptName, but found Import"; err.Error() != want {
+		t.Errorf("Parse() returned error %q, want %q", err.Error(), want)
+	}
+}
+
+func TestScriptDocComment(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"same_line_break", "ScriptName Foo\n{Documentation}\nImport Bar\n"},
+		{"blank_line", "ScriptName Foo\n\n{Documentation}\nImport Bar\n"},
+		{"blank_lines_crlf", "ScriptName Foo\r\n\r\n\r\n{Documentation}\r\nImport Bar\r\n"},
+		{"line_comments", "ScriptName Foo\n; A comment\n\n;/ Another /;\n{Documentation}\nImport Bar\n"},
+	}
+	for _, test := range tests {
+		for _, keep := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s_%t", test.name, keep), func(t *testing.T) {
+				got, err := parser.New(parser.WithLooseComments(keep)).Parse(&source.File{Text: []byte(test.input)})
+				if err != nil {
+					t.Fatalf("Parse() returned an unexpected error: %v", err)
+				}
+				if got.Comment == nil || got.Comment.Text != "{Documentation}" {
+					t.Errorf("Comment = %v, want {Documentation}", got.Comment)
+				}
+				if len(got.Statements) != 1 {
+					t.Fatalf("Statements has %d statements, want 1", len(got.Statements))
+				}
+				if _, ok := got.Statements[0].(*ast.Import); !ok {
+					t.Errorf("Statements[0] is %T, want *ast.Import", got.Statements[0])
+				}
+			})
+		}
+	}
+}
+
+// optionalChildren are the node fields that may hold nil in a parsed AST.
+var optionalChildren = map[string]bool{
+	"Script.Extends":         true,
+	"Script.Comment":         true,
+	"Event.Comment":          true,
+	"Function.ReturnType":    true,
+	"Function.Comment":       true,
+	"Property.Comment":       true,
+	"Property.Value":         true,
+	"Property.Get":           true,
+	"Property.Set":           true,
+	"ScriptVariable.Value":   true,
+	"FunctionVariable.Value": true,
+	"Return.Value":           true,
+}
+
+func TestNoMissingChildren(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "header",
+			input: "ScriptName Foo",
+		},
+		{
+			name:  "header_flags",
+			input: "ScriptName Foo Extends Bar Hidden Conditional",
+		},
+		{
+			name:  "doc_comment",
+			input: "ScriptName Foo\n{Doc}",
+		},
+		{
+			name:  "header_comments",
+			input: "; line\n;/ block /;\nScriptName Foo",
+		},
+		{
+			name:  "import",
+			input: "ScriptName Foo\nImport Bar",
+		},
+		{
+			name:  "state",
+			input: "ScriptName Foo\nState Bar\nEndState",
+		},
+		{
+			name:  "auto_state",
+			input: "ScriptName Foo\nAuto State Bar\nEndState",
+		},
+		{
+			name:  "recovery",
+			input: "ScriptName Foo\nImport Self\nState RETURN\nEndState\nImport Bar",
+		},
+	}
+	nodeType := reflect.TypeFor[ast.Node]()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := &source.File{Text: []byte(test.input)}
+			script, err := parser.New(parser.WithLooseComments(true)).Parse(f)
+			if err != nil {
+				t.Fatalf("Parse() returned an unexpected error: %v", err)
+			}
+			ast.Inspect(script, func(n ast.Node) bool {
+				v := reflect.ValueOf(n).Elem()
+				for i := range v.NumField() {
+					field := v.Type().Field(i)
+					name := v.Type().Name() + "." + field.Name
+					value := v.Field(i)
+					switch {
+					case value.Kind() == reflect.Slice && field.Type.Elem().Implements(nodeType):
+						for j := range value.Len() {
+							if value.Index(j).IsNil() {
+								t.Errorf("Parse() left %s[%d] nil", name, j)
+							}
+						}
+					case field.Type.Implements(nodeType) && !optionalChildren[name]:
+						if value.IsNil() {
+							t.Errorf("Parse() left %s nil", name)
+						}
+					}
+				}
+				return true
+			})
+		})
+	}
+}
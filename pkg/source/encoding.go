@@ -0,0 +1,112 @@
+package source
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Encoding is a character encoding a source code file may be stored in.
+//
+// Regardless of the encoding on disk, the text of a [File] is always UTF-8.
+type Encoding int
+
+const (
+	// UTF8 is UTF-8, with or without a byte order mark.
+	UTF8 Encoding = iota
+	// Windows1252 is the Windows-1252 (i.e. CP-1252) single byte encoding used
+	// by many scripts written for Skyrim.
+	Windows1252
+)
+
+// String returns the name of the encoding.
+func (e Encoding) String() string {
+	switch e {
+	case UTF8:
+		return "UTF-8"
+	case Windows1252:
+		return "Windows-1252"
+	}
+	return "<unknown>"
+}
+
+// windows1252 maps the bytes 0x80 through 0x9F to the characters they encode
+// in Windows-1252; all other bytes encode the character with the same value.
+//
+// The five bytes Windows-1252 leaves undefined map to the C1 control
+// characters with the same value so that decoding is lossless.
+var windows1252 = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡',
+	'ˆ', '‰', 'Š', '‹', 'Œ', '\u008D', 'Ž', '\u008F',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—',
+	'˜', '™', 'š', '›', 'œ', '\u009D', 'ž', 'Ÿ',
+}
+
+// NewFileFromBytes returns a [*File] for the given path with the text decoded
+// from data in the given encoding.
+//
+// Ranges into the returned file refer to the decoded text, so columns count
+// the characters of the original file; use [File.OriginalOffset] to find the
+// offset of a range in data.
+func NewFileFromBytes(path string, data []byte, enc Encoding) *File {
+	text := data
+	if enc == Windows1252 {
+		text = make([]byte, 0, len(data))
+		for _, b := range data {
+			text = utf8.AppendRune(text, decode1252(b))
+		}
+	}
+	f := NewFile(path, text)
+	f.Encoding = enc
+	return f
+}
+
+// OriginalOffset returns the byte offset in the file as encoded on disk that
+// corresponds to the given offset into the text of the file.
+func (f *File) OriginalOffset(offset int) int {
+	offset = clamp(offset, 0, len(f.Text))
+	if f.Encoding == Windows1252 {
+		return utf8.RuneCount(f.Text[:offset])
+	}
+	return offset
+}
+
+// Encode returns the UTF-8 text encoded in the given encoding.
+//
+// An error is returned if the text contains a character the encoding cannot
+// represent.
+func Encode(text []byte, enc Encoding) ([]byte, error) {
+	switch enc {
+	case UTF8:
+		return text, nil
+	case Windows1252:
+		data := make([]byte, 0, len(text))
+		for i, c := range string(text) {
+			b, ok := encode1252(c)
+			if !ok {
+				return nil, fmt.Errorf("character %q at byte %d cannot be encoded in %s", c, i, enc)
+			}
+			data = append(data, b)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("unknown encoding %d", enc)
+}
+
+func decode1252(b byte) rune {
+	if b >= 0x80 && b <= 0x9F {
+		return windows1252[b-0x80]
+	}
+	return rune(b)
+}
+
+func encode1252(c rune) (byte, bool) {
+	if c < 0x80 || (c > 0x9F && c <= 0xFF) {
+		return byte(c), true
+	}
+	for i, r := range windows1252 {
+		if r == c {
+			return byte(0x80 + i), true
+		}
+	}
+	return 0, false
+}
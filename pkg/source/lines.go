@@ -0,0 +1,98 @@
+package source
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// LineIndex maps between byte offsets and lines and columns in a file.
+//
+// Lines are terminated by "\n", "\r\n", or a lone "\r", matching the lexer.
+// The last line is terminated by the end of the file, so text that ends with a
+// line terminator has an empty last line. A leading byte order mark is not
+// part of the first line and does not occupy a column.
+type LineIndex struct {
+	file *File
+	// starts holds the byte offset of the first character of each line.
+	starts []int
+	// ends holds the byte offset of the terminator of each line.
+	ends []int
+}
+
+// NewLineIndex returns a [*LineIndex] for the current text of a file.
+//
+// Most callers should use [File.Lines] instead, which builds the index once.
+func NewLineIndex(file *File) *LineIndex {
+	x := &LineIndex{file: file}
+	text := file.Text
+	start := 0
+	if file.HasBOM() {
+		start = len(bom)
+	}
+	for i := start; i < len(text); i++ {
+		switch text[i] {
+		case '\n':
+			x.add(start, i)
+			start = i + 1
+		case '\r':
+			end := i
+			if i+1 < len(text) && text[i+1] == '\n' {
+				i++
+			}
+			x.add(start, end)
+			start = i + 1
+		}
+	}
+	x.add(start, len(text))
+	return x
+}
+
+func (x *LineIndex) add(start, end int) {
+	x.starts = append(x.starts, start)
+	x.ends = append(x.ends, end)
+}
+
+// Count returns the number of lines in the file, which is always at least one.
+func (x *LineIndex) Count() int {
+	return len(x.starts)
+}
+
+// Position returns the 1-indexed line and column of a byte offset.
+//
+// The offset is clamped to the bounds of the file.
+func (x *LineIndex) Position(offset int) (line, column int) {
+	offset = clamp(offset, x.starts[0], len(x.file.Text))
+	i := sort.Search(len(x.starts), func(i int) bool {
+		return x.starts[i] > offset
+	}) - 1
+	return i + 1, utf8.RuneCount(x.file.Text[x.starts[i]:offset]) + 1
+}
+
+// Offset returns the byte offset of a 1-indexed line and column.
+//
+// The line is clamped to the lines of the file and the column to the
+// characters of that line, so a column past the end of a line is the offset of
+// its terminator.
+func (x *LineIndex) Offset(line, column int) int {
+	i := clamp(line, 1, len(x.starts)) - 1
+	offset, end := x.starts[i], x.ends[i]
+	for ; column > 1 && offset < end; column-- {
+		_, w := utf8.DecodeRune(x.file.Text[offset:end])
+		offset += w
+	}
+	return offset
+}
+
+// Line returns the range of a 1-indexed line, excluding its terminator.
+//
+// The line is clamped to the lines of the file.
+func (x *LineIndex) Line(line int) Range {
+	i := clamp(line, 1, len(x.starts)) - 1
+	return Range{
+		File:       x.file,
+		ByteOffset: x.starts[i],
+		Length:     x.ends[i] - x.starts[i],
+		Line:       i + 1,
+		Column:     1,
+	}
+}
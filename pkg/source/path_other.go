@@ -0,0 +1,8 @@
+//go:build !windows
+
+package source
+
+// pathKey returns the key used to compare the given (clean) path.
+func pathKey(path string) string {
+	return path
+}
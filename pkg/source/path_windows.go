@@ -0,0 +1,13 @@
+package source
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// pathKey returns the key used to compare the given (clean) path.
+//
+// Windows file systems are case-insensitive and accept either separator.
+func pathKey(path string) string {
+	return strings.ToLower(filepath.ToSlash(path))
+}
@@ -1,15 +1,120 @@
 // Package source provides utilities for referring to source code.
 package source
 
+import (
+	"bytes"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
 // File contains information for a source code file.
 type File struct {
 	// The path of the file.
 	Path string
-	// The full text of the file.
+	// The full text of the file, always encoded as UTF-8.
 	Text []byte
+	// Encoding is the encoding of the file on disk.
+	Encoding Encoding
+
+	// key is the platform-specific comparison key for Path.
+	key string
+	// lines is the line index of Text, built on first use.
+	lines atomic.Pointer[LineIndex]
+}
+
+// NewFile returns a [*File] for the given path and text.
+//
+// The path is cleaned and made absolute when possible. Its case is preserved,
+// but on platforms with case-insensitive file systems (i.e. Windows) files are
+// compared case-insensitively by [File.SameAs].
+func NewFile(path string, text []byte) *File {
+	if path != "" {
+		path = absPath(path)
+	}
+	return &File{
+		Path: path,
+		Text: text,
+		key:  pathKey(path),
+	}
+}
+
+// SameAs returns true if this file and other refer to the same file on disk.
+//
+// Files without a path are only the same as themselves.
+func (f *File) SameAs(other *File) bool {
+	if f == other {
+		return true
+	}
+	if f == nil || other == nil || f.Path == "" || other.Path == "" {
+		return false
+	}
+	return f.comparisonKey() == other.comparisonKey()
+}
+
+// comparisonKey returns the key used to compare the file by path, which for a
+// File not made by [NewFile] is computed from Path as NewFile would.
+func (f *File) comparisonKey() string {
+	if f.key != "" {
+		return f.key
+	}
+	return pathKey(absPath(f.Path))
+}
+
+// absPath returns path cleaned and made absolute when possible.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// Lines returns the line index of the file, building it on first use.
+//
+// The index reflects the text of the file when it was first built, so Text
+// must not be modified afterward.
+func (f *File) Lines() *LineIndex {
+	if x := f.lines.Load(); x != nil {
+		return x
+	}
+	x := NewLineIndex(f)
+	if f.lines.CompareAndSwap(nil, x) {
+		return x
+	}
+	return f.lines.Load()
+}
+
+// bom is the UTF-8 encoding of the byte order mark.
+const bom = "\xEF\xBB\xBF"
+
+// HasBOM returns true if the text of the file starts with a UTF-8 byte order
+// mark.
+func (f *File) HasBOM() bool {
+	return bytes.HasPrefix(f.Text, []byte(bom))
+}
+
+// SlashPath returns the path of the file using forward slashes as separators
+// regardless of platform.
+func (f *File) SlashPath() string {
+	return filepath.ToSlash(f.Path)
+}
+
+// URI returns the path of the file as a file URI (e.g. "file:///C:/foo.psc").
+func (f *File) URI() string {
+	path := f.SlashPath()
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
 }
 
 // Range points to a range of bytes in a source code file.
+//
+// A range is half-open: it covers the bytes from ByteOffset up to, but not
+// including, ByteOffset+Length. A range with a Length of zero is an insertion
+// point immediately before the byte at ByteOffset.
 type Range struct {
 	// File is the file that contains the range.
 	File *File
@@ -21,12 +126,19 @@ type Range struct {
 	// Line is the 1-indexed line of start of the range in the file.
 	Line int
 	// Column is the 1-indexed column start of the range in the file.
+	//
+	// Columns count characters (i.e. runes), not bytes.
 	Column int
 }
 
+// End returns the byte offset immediately after the last byte in the range.
+func (r Range) End() int {
+	return r.ByteOffset + r.Length
+}
+
 // Text returns the text this range represents.
 func (r Range) Text() []byte {
-	return r.File.Text[r.ByteOffset : r.ByteOffset+r.Length]
+	return r.File.Text[r.ByteOffset:r.End()]
 }
 
 // Span returns a Range that spans two given Ranges.
@@ -34,8 +146,101 @@ func Span(start, end Range) Range {
 	return Range{
 		File:       start.File,
 		ByteOffset: start.ByteOffset,
-		Length:     end.ByteOffset - start.ByteOffset + end.Length,
+		Length:     end.End() - start.ByteOffset,
 		Line:       start.Line,
-		Column:     start.Line,
+		Column:     start.Column,
+	}
+}
+
+// At returns a zero-length Range at the given byte offset in a file.
+//
+// The offset is clamped to the bounds of the file.
+func At(file *File, offset int) Range {
+	offset = clamp(offset, 0, len(file.Text))
+	line, column := file.position(offset)
+	return Range{
+		File:       file,
+		ByteOffset: offset,
+		Line:       line,
+		Column:     column,
+	}
+}
+
+// Shift returns the range moved delta bytes through its file with its line
+// and column recomputed.
+//
+// The result never extends past the bounds of the file; a range shifted
+// beyond either end of the file is truncated.
+func (r Range) Shift(delta int) Range {
+	start := clamp(r.ByteOffset+delta, 0, len(r.File.Text))
+	end := clamp(r.End()+delta, start, len(r.File.Text))
+	return r.slice(start, end)
+}
+
+// TrimTo returns the range with all leading and trailing characters for which
+// trim returns true removed.
+//
+// If every character is trimmed, the result is a zero-length range at the
+// start of the original range.
+func (r Range) TrimTo(trim func(rune) bool) Range {
+	text := r.Text()
+	start := r.ByteOffset
+	for len(text) > 0 {
+		c, w := utf8.DecodeRune(text)
+		if !trim(c) {
+			break
+		}
+		text = text[w:]
+		start += w
+	}
+	if len(text) == 0 {
+		return r.slice(r.ByteOffset, r.ByteOffset)
+	}
+	end := r.End()
+	for len(text) > 0 {
+		c, w := utf8.DecodeLastRune(text)
+		if !trim(c) {
+			break
+		}
+		text = text[:len(text)-w]
+		end -= w
 	}
+	return r.slice(start, end)
+}
+
+// FirstLine returns the portion of the range on its first line, excluding the
+// line terminator, which is "\n", "\r\n", or a lone "\r" as in the lexer.
+func (r Range) FirstLine() Range {
+	i := bytes.IndexAny(r.Text(), "\r\n")
+	if i < 0 {
+		return r
+	}
+	return Range{
+		File:       r.File,
+		ByteOffset: r.ByteOffset,
+		Length:     i,
+		Line:       r.Line,
+		Column:     r.Column,
+	}
+}
+
+// slice returns the range covering [start, end) in the same file.
+func (r Range) slice(start, end int) Range {
+	line, column := r.File.position(start)
+	return Range{
+		File:       r.File,
+		ByteOffset: start,
+		Length:     end - start,
+		Line:       line,
+		Column:     column,
+	}
+}
+
+// position returns the 1-indexed line and column of a byte offset.
+func (f *File) position(offset int) (line, column int) {
+	return f.Lines().Position(offset)
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
 }
@@ -0,0 +1,559 @@
+package source_test
+
+import (
+	"math/rand/v2"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/TLBuf/papyrus/pkg/source"
+)
+
+func TestNewFilePath(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{
+			name: "clean",
+			path: "/scripts/source/foo.psc",
+			want: "/scripts/source/foo.psc",
+		},
+		{
+			name: "dot_dot",
+			path: "/scripts/other/../source/./foo.psc",
+			want: "/scripts/source/foo.psc",
+		},
+		{
+			name: "case_preserved",
+			path: "/Scripts/Source/Foo.psc",
+			want: "/Scripts/Source/Foo.psc",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			want, err := filepath.Abs(filepath.FromSlash(test.want))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := source.NewFile(filepath.FromSlash(test.path), nil)
+			if got.Path != want {
+				t.Errorf("Path = %q, want %q", got.Path, want)
+			}
+		})
+	}
+}
+
+func TestNewFileRelative(t *testing.T) {
+	got := source.NewFile("foo.psc", nil)
+	if !filepath.IsAbs(got.Path) {
+		t.Errorf("Path = %q, want an absolute path", got.Path)
+	}
+}
+
+func TestSameAs(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b *source.File
+		want bool
+	}{
+		{
+			name: "identical",
+			a:    source.NewFile("/scripts/foo.psc", nil),
+			b:    source.NewFile("/scripts/foo.psc", nil),
+			want: true,
+		},
+		{
+			name: "unclean",
+			a:    source.NewFile("/scripts/foo.psc", nil),
+			b:    source.NewFile("/scripts/source/../foo.psc", nil),
+			want: true,
+		},
+		{
+			name: "different",
+			a:    source.NewFile("/scripts/foo.psc", nil),
+			b:    source.NewFile("/scripts/bar.psc", nil),
+			want: false,
+		},
+		{
+			name: "literal",
+			a:    &source.File{Path: "/scripts/foo.psc"},
+			b:    source.NewFile("/scripts/foo.psc", nil),
+			want: true,
+		},
+		{
+			name: "relative_literal",
+			a:    &source.File{Path: "scripts/foo.psc"},
+			b:    source.NewFile("scripts/foo.psc", nil),
+			want: true,
+		},
+		{
+			name: "unclean_relative_literal",
+			a:    &source.File{Path: "scripts/../foo.psc"},
+			b:    source.NewFile("foo.psc", nil),
+			want: true,
+		},
+		{
+			name: "no_path",
+			a:    &source.File{},
+			b:    &source.File{},
+			want: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.a.SameAs(test.b); got != test.want {
+				t.Errorf("SameAs() = %t, want %t", got, test.want)
+			}
+			if got := test.b.SameAs(test.a); got != test.want {
+				t.Errorf("SameAs() (reversed) = %t, want %t", got, test.want)
+			}
+		})
+	}
+	f := &source.File{}
+	if !f.SameAs(f) {
+		t.Errorf("SameAs() = false for the same *File, want true")
+	}
+}
+
+func TestSlashPath(t *testing.T) {
+	f := &source.File{Path: filepath.Join("scripts", "source", "foo.psc")}
+	if got, want := f.SlashPath(), "scripts/source/foo.psc"; got != want {
+		t.Errorf("SlashPath() = %q, want %q", got, want)
+	}
+}
+
+func TestURI(t *testing.T) {
+	f := &source.File{Path: filepath.FromSlash("/scripts/my source/foo.psc")}
+	got := f.URI()
+	if !strings.HasPrefix(got, "file:///") {
+		t.Errorf("URI() = %q, want a file:/// prefix", got)
+	}
+	if want := "/scripts/my%20source/foo.psc"; !strings.HasSuffix(got, want) {
+		t.Errorf("URI() = %q, want suffix %q", got, want)
+	}
+}
+
+func TestSpan(t *testing.T) {
+	file := source.NewFile("test.psc", []byte("foo\n  bar baz"))
+	start := source.At(file, 6)
+	start.Length = 3
+	end := source.At(file, 10)
+	end.Length = 3
+	got := source.Span(start, end)
+	want := source.Range{File: file, ByteOffset: 6, Length: 7, Line: 2, Column: 3}
+	if got != want {
+		t.Errorf("Span() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAt(t *testing.T) {
+	file := source.NewFile("test.psc", []byte("ab\ncé\nd"))
+	tests := []struct {
+		name   string
+		offset int
+		want   source.Range
+	}{
+		{
+			name:   "start",
+			offset: 0,
+			want:   source.Range{ByteOffset: 0, Line: 1, Column: 1},
+		},
+		{
+			name:   "newline",
+			offset: 2,
+			want:   source.Range{ByteOffset: 2, Line: 1, Column: 3},
+		},
+		{
+			name:   "second_line",
+			offset: 3,
+			want:   source.Range{ByteOffset: 3, Line: 2, Column: 1},
+		},
+		{
+			name:   "after_multibyte",
+			offset: 6,
+			want:   source.Range{ByteOffset: 6, Line: 2, Column: 3},
+		},
+		{
+			name:   "end",
+			offset: 8,
+			want:   source.Range{ByteOffset: 8, Line: 3, Column: 2},
+		},
+		{
+			name:   "negative",
+			offset: -4,
+			want:   source.Range{ByteOffset: 0, Line: 1, Column: 1},
+		},
+		{
+			name:   "past_end",
+			offset: 100,
+			want:   source.Range{ByteOffset: 8, Line: 3, Column: 2},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.want.File = file
+			if got := source.At(file, test.offset); got != test.want {
+				t.Errorf("At(%d) = %+v, want %+v", test.offset, got, test.want)
+			}
+		})
+	}
+}
+
+func TestShift(t *testing.T) {
+	file := source.NewFile("test.psc", []byte("one\ntwo\nthree"))
+	tests := []struct {
+		name  string
+		start int
+		len   int
+		delta int
+		want  source.Range
+	}{
+		{
+			name:  "zero",
+			start: 4,
+			len:   3,
+			delta: 0,
+			want:  source.Range{ByteOffset: 4, Length: 3, Line: 2, Column: 1},
+		},
+		{
+			name:  "forward_across_line",
+			start: 4,
+			len:   3,
+			delta: 5,
+			want:  source.Range{ByteOffset: 9, Length: 3, Line: 3, Column: 2},
+		},
+		{
+			name:  "backward",
+			start: 8,
+			len:   5,
+			delta: -7,
+			want:  source.Range{ByteOffset: 1, Length: 5, Line: 1, Column: 2},
+		},
+		{
+			name:  "truncated_at_end",
+			start: 8,
+			len:   5,
+			delta: 2,
+			want:  source.Range{ByteOffset: 10, Length: 3, Line: 3, Column: 3},
+		},
+		{
+			name:  "truncated_at_start",
+			start: 1,
+			len:   4,
+			delta: -3,
+			want:  source.Range{ByteOffset: 0, Length: 2, Line: 1, Column: 1},
+		},
+		{
+			name:  "past_end",
+			start: 4,
+			len:   3,
+			delta: 100,
+			want:  source.Range{ByteOffset: 13, Length: 0, Line: 3, Column: 6},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := source.At(file, test.start)
+			r.Length = test.len
+			test.want.File = file
+			if got := r.Shift(test.delta); got != test.want {
+				t.Errorf("Shift(%d) = %+v, want %+v", test.delta, got, test.want)
+			}
+		})
+	}
+}
+
+func TestShiftSpan(t *testing.T) {
+	file := source.NewFile("test.psc", []byte("alpha\nbeta\ngamma\ndelta"))
+	start := source.At(file, 0)
+	start.Length = 5
+	end := source.At(file, 6)
+	end.Length = 4
+	for delta := range 8 {
+		got := source.Span(start.Shift(delta), end.Shift(delta))
+		want := source.Span(start, end).Shift(delta)
+		if got != want {
+			t.Errorf("Span of ranges shifted by %d = %+v, want %+v", delta, got, want)
+		}
+	}
+}
+
+func TestTrimTo(t *testing.T) {
+	file := source.NewFile("test.psc", []byte("x\n  \tfoo bar\t \ny"))
+	tests := []struct {
+		name  string
+		start int
+		len   int
+		want  source.Range
+	}{
+		{
+			name:  "both_ends",
+			start: 1,
+			len:   14,
+			want:  source.Range{ByteOffset: 5, Length: 7, Line: 2, Column: 4},
+		},
+		{
+			name:  "nothing_to_trim",
+			start: 5,
+			len:   7,
+			want:  source.Range{ByteOffset: 5, Length: 7, Line: 2, Column: 4},
+		},
+		{
+			name:  "all_trimmed",
+			start: 1,
+			len:   4,
+			want:  source.Range{ByteOffset: 1, Length: 0, Line: 1, Column: 2},
+		},
+		{
+			name:  "empty",
+			start: 8,
+			len:   0,
+			want:  source.Range{ByteOffset: 8, Length: 0, Line: 2, Column: 7},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := source.At(file, test.start)
+			r.Length = test.len
+			test.want.File = file
+			if got := r.TrimTo(unicode.IsSpace); got != test.want {
+				t.Errorf("TrimTo() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFirstLine(t *testing.T) {
+	file := source.NewFile("test.psc", []byte("If x\r\n  y\nEndIf\rZ"))
+	tests := []struct {
+		name  string
+		start int
+		len   int
+		want  int
+	}{
+		{
+			name:  "crlf",
+			start: 0,
+			len:   15,
+			want:  4,
+		},
+		{
+			name:  "lf",
+			start: 6,
+			len:   9,
+			want:  3,
+		},
+		{
+			name:  "single_line",
+			start: 10,
+			len:   5,
+			want:  5,
+		},
+		{
+			name:  "lone_cr",
+			start: 10,
+			len:   7,
+			want:  5,
+		},
+		{
+			name:  "starts_at_newline",
+			start: 9,
+			len:   6,
+			want:  0,
+		},
+		{
+			name:  "starts_at_cr",
+			start: 4,
+			len:   4,
+			want:  0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := source.At(file, test.start)
+			r.Length = test.len
+			got := r.FirstLine()
+			want := r
+			want.Length = test.want
+			if got != want {
+				t.Errorf("FirstLine() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestEnd(t *testing.T) {
+	r := source.Range{ByteOffset: 3, Length: 4}
+	if got := r.End(); got != 7 {
+		t.Errorf("End() = %d, want 7", got)
+	}
+}
+
+func TestHasBOM(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want bool
+	}{
+		{"bom", "\xEF\xBB\xBFScriptName Foo", true},
+		{"no_bom", "ScriptName Foo", false},
+		{"partial", "\xEF\xBB", false},
+		{"empty", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := source.NewFile("test.psc", []byte(test.text))
+			if got := f.HasBOM(); got != test.want {
+				t.Errorf("HasBOM() = %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewFileFromBytesWindows1252(t *testing.T) {
+	data := []byte("String s = \"caf\xE9\x99\" ; \x93quoted\x94")
+	f := source.NewFileFromBytes("", data, source.Windows1252)
+	if want := "String s = \"café™\" ; “quoted”"; string(f.Text) != want {
+		t.Errorf("Text = %q, want %q", f.Text, want)
+	}
+	if f.Encoding != source.Windows1252 {
+		t.Errorf("Encoding = %v, want %v", f.Encoding, source.Windows1252)
+	}
+	semicolon := strings.IndexByte(string(f.Text), ';')
+	if got, want := f.OriginalOffset(semicolon), strings.IndexByte(string(data), ';'); got != want {
+		t.Errorf("OriginalOffset(%d) = %d, want %d", semicolon, got, want)
+	}
+	if got, want := source.At(f, semicolon).Column, 20; got != want {
+		t.Errorf("Column = %d, want %d", got, want)
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		enc  source.Encoding
+		data []byte
+	}{
+		{
+			name: "utf8",
+			enc:  source.UTF8,
+			data: []byte("\xEF\xBB\xBFString s = \"café\""),
+		},
+		{
+			name: "windows1252",
+			enc:  source.Windows1252,
+			data: func() []byte {
+				var data []byte
+				for b := range 256 {
+					data = append(data, byte(b))
+				}
+				return data
+			}(),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := source.NewFileFromBytes("", test.data, test.enc)
+			got, err := source.Encode(f.Text, test.enc)
+			if err != nil {
+				t.Fatalf("Encode() returned an unexpected error: %v", err)
+			}
+			if string(got) != string(test.data) {
+				t.Errorf("Encode() = %q, want %q", got, test.data)
+			}
+		})
+	}
+}
+
+func TestEncodeUnrepresentable(t *testing.T) {
+	if _, err := source.Encode([]byte("日本"), source.Windows1252); err == nil {
+		t.Error("Encode() returned no error, want an error")
+	}
+}
+
+func TestLineIndex(t *testing.T) {
+	f := source.NewFile("", []byte("\xEF\xBB\xBFab\r\ncafé\rx\n\nlast"))
+	lines := f.Lines()
+	if got, want := lines.Count(), 5; got != want {
+		t.Fatalf("Count() = %d, want %d", got, want)
+	}
+	tests := []struct {
+		offset       int
+		line, column int
+	}{
+		{0, 1, 1},
+		{3, 1, 1},
+		{4, 1, 2},
+		{5, 1, 3},
+		{7, 2, 1},
+		{12, 2, 5},
+		{13, 3, 1},
+		{15, 4, 1},
+		{16, 5, 1},
+		{20, 5, 5},
+		{99, 5, 5},
+	}
+	for _, test := range tests {
+		line, column := lines.Position(test.offset)
+		if line != test.line || column != test.column {
+			t.Errorf("Position(%d) = (%d, %d), want (%d, %d)", test.offset, line, column, test.line, test.column)
+		}
+	}
+	ranges := []struct {
+		line int
+		text string
+	}{
+		{0, "ab"},
+		{1, "ab"},
+		{2, "café"},
+		{3, "x"},
+		{4, ""},
+		{5, "last"},
+		{6, "last"},
+	}
+	for _, test := range ranges {
+		if got := string(lines.Line(test.line).Text()); got != test.text {
+			t.Errorf("Line(%d).Text() = %q, want %q", test.line, got, test.text)
+		}
+	}
+	if got, want := lines.Offset(2, 99), 12; got != want {
+		t.Errorf("Offset(2, 99) = %d, want %d", got, want)
+	}
+}
+
+func TestLineIndexInverse(t *testing.T) {
+	alphabet := []string{"a", "Z", " ", "\t", "é", "日", "\n", "\r\n", "\r"}
+	r := rand.New(rand.NewPCG(1, 2))
+	for range 200 {
+		var text strings.Builder
+		if r.IntN(4) == 0 {
+			text.WriteString("\uFEFF")
+		}
+		for range r.IntN(40) {
+			text.WriteString(alphabet[r.IntN(len(alphabet))])
+		}
+		f := source.NewFile("", []byte(text.String()))
+		lines := f.Lines()
+		for line := 1; line <= lines.Count(); line++ {
+			l := lines.Line(line)
+			for offset := l.ByteOffset; offset <= l.End(); {
+				gotLine, gotColumn := lines.Position(offset)
+				if gotLine != line {
+					t.Fatalf("%q: Position(%d) line = %d, want %d", text.String(), offset, gotLine, line)
+				}
+				if got := lines.Offset(gotLine, gotColumn); got != offset {
+					t.Fatalf("%q: Offset(Position(%d)) = %d", text.String(), offset, got)
+				}
+				if offset == l.End() {
+					break
+				}
+				_, w := utf8.DecodeRune(f.Text[offset:])
+				offset += w
+			}
+		}
+	}
+}
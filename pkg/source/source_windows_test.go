@@ -0,0 +1,53 @@
+package source_test
+
+import (
+	"testing"
+
+	"github.com/TLBuf/papyrus/pkg/source"
+)
+
+func TestSameAsWindows(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{
+			name: "case",
+			a:    `C:\Scripts\Source\foo.psc`,
+			b:    `c:\scripts\source\Foo.psc`,
+			want: true,
+		},
+		{
+			name: "separators",
+			a:    `C:\Scripts\Source\foo.psc`,
+			b:    `C:/Scripts/Source/foo.psc`,
+			want: true,
+		},
+		{
+			name: "different",
+			a:    `C:\Scripts\Source\foo.psc`,
+			b:    `C:\Scripts\Source\bar.psc`,
+			want: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := source.NewFile(test.a, nil)
+			b := source.NewFile(test.b, nil)
+			if got := a.SameAs(b); got != test.want {
+				t.Errorf("SameAs() = %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSlashPathWindows(t *testing.T) {
+	f := source.NewFile(`C:\Scripts\Source\Foo.psc`, nil)
+	if got, want := f.SlashPath(), "C:/Scripts/Source/Foo.psc"; got != want {
+		t.Errorf("SlashPath() = %q, want %q", got, want)
+	}
+	if got, want := f.URI(), "file:///C:/Scripts/Source/Foo.psc"; got != want {
+		t.Errorf("URI() = %q, want %q", got, want)
+	}
+}
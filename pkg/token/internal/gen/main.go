@@ -0,0 +1,139 @@
+// Command gen generates the token type tables for the token package from the
+// table in this directory.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"strings"
+)
+
+var output = flag.String("output", "types.go", "file to write the generated code to")
+
+func main() {
+	flag.Parse()
+	src, err := generate()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func generate() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by internal/gen; DO NOT EDIT.\n\npackage token\n\n")
+
+	b.WriteString("// The set of tokens for Papyrus.\nconst (\n")
+	for i, t := range tokenTypes {
+		if i == 0 {
+			fmt.Fprintf(&b, "%s Type = iota\n", t.Name)
+		} else {
+			fmt.Fprintf(&b, "%s\n", t.Name)
+		}
+	}
+	b.WriteString("\n// typeCount is the number of token types.\ntypeCount int = iota\n)\n\n")
+
+	b.WriteString("var names = [typeCount]string{\n")
+	for _, t := range tokenTypes {
+		fmt.Fprintf(&b, "%s: %q,\n", t.Name, t.Name)
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("var texts = [typeCount]string{\n")
+	for _, t := range tokenTypes {
+		if t.Text != "" {
+			fmt.Fprintf(&b, "%s: %q,\n", t.Name, t.Text)
+		}
+	}
+	b.WriteString("}\n\n")
+
+	if err := writeKeywordTrie(&b); err != nil {
+		return nil, err
+	}
+
+	b.WriteString("var isKeyword = [typeCount]bool{\n")
+	for _, t := range tokenTypes {
+		if t.Category == keyword {
+			fmt.Fprintf(&b, "%s: true,\n", t.Name)
+		}
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("var precedences = [typeCount]int{\n")
+	for _, t := range tokenTypes {
+		if t.Precedence > 0 {
+			fmt.Fprintf(&b, "%s: %d,\n", t.Name, t.Precedence)
+		}
+	}
+	b.WriteString("}\n")
+
+	return format.Source(b.Bytes())
+}
+
+// trieNode is a node in the keyword trie.
+type trieNode struct {
+	children [26]int
+	keyword  string
+}
+
+// writeKeywordTrie writes a trie over the case-folded letters of every keyword
+// so keywords can be matched without allocating a lowercase copy of the text.
+func writeKeywordTrie(b *bytes.Buffer) error {
+	nodes := []trieNode{{}}
+	for _, t := range tokenTypes {
+		if t.Category != keyword {
+			continue
+		}
+		node := 0
+		for _, c := range strings.ToLower(t.Text) {
+			if c < 'a' || c > 'z' {
+				return fmt.Errorf("keyword %s contains %q, but keywords may only contain letters", t.Name, c)
+			}
+			next := nodes[node].children[c-'a']
+			if next == 0 {
+				next = len(nodes)
+				nodes = append(nodes, trieNode{})
+				nodes[node].children[c-'a'] = next
+			}
+			node = next
+		}
+		nodes[node].keyword = t.Name
+	}
+	if len(nodes) > 256 {
+		return fmt.Errorf("keyword trie has %d nodes, but at most 256 fit in a uint8", len(nodes))
+	}
+
+	b.WriteString("// keywordTrie is a trie over the lowercase letters of every keyword. Each\n")
+	b.WriteString("// node holds the index of its child node for each letter or zero if there is\n")
+	b.WriteString("// none (the root is never a child).\n")
+	fmt.Fprintf(b, "var keywordTrie = [%d][26]uint8{\n", len(nodes))
+	for i, n := range nodes {
+		var children []string
+		for c, child := range n.children {
+			if child != 0 {
+				children = append(children, fmt.Sprintf("'%c' - 'a': %d", 'a'+c, child))
+			}
+		}
+		if len(children) > 0 {
+			fmt.Fprintf(b, "%d: {%s},\n", i, strings.Join(children, ", "))
+		}
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("// keywordEnds holds the keyword that ends at each node in keywordTrie or\n")
+	b.WriteString("// Illegal if none does.\n")
+	fmt.Fprintf(b, "var keywordEnds = [%d]Type{\n", len(nodes))
+	for i, n := range nodes {
+		if n.keyword != "" {
+			fmt.Fprintf(b, "%d: %s,\n", i, n.keyword)
+		}
+	}
+	b.WriteString("}\n\n")
+	return nil
+}
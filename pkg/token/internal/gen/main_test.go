@@ -0,0 +1,21 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGeneratedUpToDate(t *testing.T) {
+	want, err := generate()
+	if err != nil {
+		t.Fatalf("generate() returned an unexpected error: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join("..", "..", "types.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("types.go is out of date, run go generate in pkg/token")
+	}
+}
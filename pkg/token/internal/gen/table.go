@@ -0,0 +1,115 @@
+package main
+
+// category is the broad classification of a token type.
+type category int
+
+const (
+	special category = iota
+	keyword
+	literal
+	operator
+	delimiter
+	comment
+)
+
+// tokenType describes a single token type.
+type tokenType struct {
+	// Name is the name of the Go constant for the type.
+	Name string
+	// Text is the canonical source text for the type (i.e. the preferred casing
+	// for keywords) or empty if the type has no fixed text.
+	Text string
+	// Category is the broad classification of the type.
+	Category category
+	// Precedence is the binding power of the type when it appears as an infix
+	// operator or zero if it never does. Higher values bind more tightly.
+	Precedence int
+}
+
+// Operator precedences from lowest to highest.
+const (
+	_ = iota
+	logicalOr
+	logicalAnd
+	comparison
+	additive
+	multiplicative
+	cast
+	postfix
+)
+
+// tokenTypes is the source of truth for every token type, in constant order.
+var tokenTypes = []tokenType{
+	{"Illegal", "", special, 0},
+	{"EOF", "", special, 0},
+	{"Add", "+", operator, additive},
+	{"As", "As", keyword, cast},
+	{"Assign", "=", operator, 0},
+	{"AssignAdd", "+=", operator, 0},
+	{"AssignDivide", "/=", operator, 0},
+	{"AssignModulo", "%=", operator, 0},
+	{"AssignMultiply", "*=", operator, 0},
+	{"AssignSubtract", "-=", operator, 0},
+	{"Auto", "Auto", keyword, 0},
+	{"AutoReadOnly", "AutoReadOnly", keyword, 0},
+	{"BlockComment", "", comment, 0},
+	{"Bool", "Bool", keyword, 0},
+	{"Comma", ",", delimiter, 0},
+	{"Conditional", "Conditional", keyword, 0},
+	{"Divide", "/", operator, multiplicative},
+	{"DocComment", "", comment, 0},
+	{"Dot", ".", delimiter, postfix},
+	{"Else", "Else", keyword, 0},
+	{"ElseIf", "ElseIf", keyword, 0},
+	{"EndEvent", "EndEvent", keyword, 0},
+	{"EndFunction", "EndFunction", keyword, 0},
+	{"EndIf", "EndIf", keyword, 0},
+	{"EndProperty", "EndProperty", keyword, 0},
+	{"EndState", "EndState", keyword, 0},
+	{"EndWhile", "EndWhile", keyword, 0},
+	{"Equal", "==", operator, comparison},
+	{"Event", "Event", keyword, 0},
+	{"Extends", "Extends", keyword, 0},
+	{"False", "False", keyword, 0},
+	{"Float", "Float", keyword, 0},
+	{"FloatLiteral", "", literal, 0},
+	{"Function", "Function", keyword, 0},
+	{"Global", "Global", keyword, 0},
+	{"Greater", ">", operator, comparison},
+	{"GreaterOrEqual", ">=", operator, comparison},
+	{"Hidden", "Hidden", keyword, 0},
+	{"Identifier", "", special, 0},
+	{"If", "If", keyword, 0},
+	{"Import", "Import", keyword, 0},
+	{"Int", "Int", keyword, 0},
+	{"IntLiteral", "", literal, 0},
+	{"LBracket", "[", delimiter, postfix},
+	{"Length", "Length", keyword, 0},
+	{"Less", "<", operator, comparison},
+	{"LessOrEqual", "<=", operator, comparison},
+	{"LineComment", "", comment, 0},
+	{"LogicalAnd", "&&", operator, logicalAnd},
+	{"LogicalNot", "!", operator, 0},
+	{"LogicalOr", "||", operator, logicalOr},
+	{"LParen", "(", delimiter, postfix},
+	{"Modulo", "%", operator, multiplicative},
+	{"Multiply", "*", operator, multiplicative},
+	{"Native", "Native", keyword, 0},
+	{"New", "New", keyword, 0},
+	{"Newline", "\n", special, 0},
+	{"None", "None", keyword, 0},
+	{"NotEqual", "!=", operator, comparison},
+	{"Parent", "Parent", keyword, 0},
+	{"Property", "Property", keyword, 0},
+	{"RBracket", "]", delimiter, 0},
+	{"Return", "Return", keyword, 0},
+	{"RParen", ")", delimiter, 0},
+	{"ScriptName", "ScriptName", keyword, 0},
+	{"Self", "Self", keyword, 0},
+	{"State", "State", keyword, 0},
+	{"String", "String", keyword, 0},
+	{"StringLiteral", "", literal, 0},
+	{"Subtract", "-", operator, additive},
+	{"True", "True", keyword, 0},
+	{"While", "While", keyword, 0},
+}
@@ -1,99 +1,47 @@
 // Package token defines the Papyrus tokens understood by the parser.
 package token
 
-import (
-	"strings"
+//go:generate go run ./internal/gen -output types.go
 
-	"github.com/TLBuf/papyrus/pkg/source"
-)
+import "github.com/TLBuf/papyrus/pkg/source"
 
 // Type is the type of token.
+//
+// The set of types is generated from the table in internal/gen.
 type Type byte
 
-// The set of tokens for Papyrus.
-const (
-	Illegal Type = iota
-	EOF
-	Add
-	As
-	Assign
-	AssignAdd
-	AssignDivide
-	AssignModulo
-	AssignMultiply
-	AssignSubtract
-	Auto
-	AutoReadOnly
-	BlockComment
-	Bool
-	Comma
-	Conditional
-	Divide
-	DocComment
-	Dot
-	Else
-	ElseIf
-	EndEvent
-	EndFunction
-	EndIf
-	EndProperty
-	EndState
-	EndWhile
-	Equal
-	Event
-	Extends
-	False
-	Float
-	FloatLiteral
-	Function
-	Global
-	Greater
-	GreaterOrEqual
-	Hidden
-	Identifier
-	If
-	Import
-	Int
-	IntLiteral
-	LBracket
-	Length
-	Less
-	LessOrEqual
-	LineComment
-	LogicalAnd
-	LogicalNot
-	LogicalOr
-	LParen
-	Modulo
-	Multiply
-	Native
-	New
-	Newline
-	None
-	NotEqual
-	Parent
-	Property
-	RBracket
-	Return
-	RParen
-	ScriptName
-	Self
-	State
-	String
-	StringLiteral
-	Subtract
-	True
-	While
-)
-
 func (t Type) String() string {
-	name, ok := names[t]
-	if ok {
-		return name
+	if int(t) < typeCount {
+		return names[t]
 	}
 	return "<unknown>"
 }
 
+// Text returns the canonical source text for the type (e.g. "EndFunction" or
+// "+=") or an empty string if the type has no fixed text (e.g. [Identifier]).
+func (t Type) Text() string {
+	if int(t) < typeCount {
+		return texts[t]
+	}
+	return ""
+}
+
+// IsKeyword returns true if the type is a reserved word (e.g. [New] or [If])
+// and therefore cannot be used as an identifier.
+func (t Type) IsKeyword() bool {
+	return int(t) < typeCount && isKeyword[t]
+}
+
+// Precedence returns the binding power of the type when it appears as an infix
+// (or postfix) operator or zero if it never does. Higher values bind more
+// tightly.
+func (t Type) Precedence() int {
+	if int(t) < typeCount {
+		return precedences[t]
+	}
+	return 0
+}
+
 // Token encodes a single lexical token in the Papyrus language.
 //
 // Each token has a [Type] and information about where it is located
@@ -104,123 +52,23 @@ type Token struct {
 }
 
 // LookupIdentifier returns the [Type] of the given identifier or keyword.
+//
+// Keywords are matched case-insensitively.
 func LookupIdentifier(ident string) Type {
-	if t, ok := keywords[strings.ToLower(ident)]; ok {
+	node := 0
+	for i := 0; i < len(ident); i++ {
+		// Setting this bit lowercases ASCII letters and moves every other byte
+		// outside of the range of lowercase letters.
+		c := ident[i] | 0x20
+		if c < 'a' || c > 'z' {
+			return Identifier
+		}
+		if node = int(keywordTrie[node][c-'a']); node == 0 {
+			return Identifier
+		}
+	}
+	if t := keywordEnds[node]; t != Illegal {
 		return t
 	}
 	return Identifier
 }
-
-var keywords = map[string]Type{
-	"as":           As,
-	"auto":         Auto,
-	"autoreadonly": AutoReadOnly,
-	"bool":         Bool,
-	"conditional":  Conditional,
-	"else":         Else,
-	"elseif":       ElseIf,
-	"endevent":     EndEvent,
-	"endfunction":  EndFunction,
-	"endif":        EndIf,
-	"endproperty":  EndProperty,
-	"endstate":     EndState,
-	"endwhile":     EndWhile,
-	"event":        Event,
-	"extends":      Extends,
-	"false":        False,
-	"float":        Float,
-	"function":     Function,
-	"global":       Global,
-	"hidden":       Hidden,
-	"if":           If,
-	"import":       Import,
-	"int":          Int,
-	"length":       Length,
-	"native":       Native,
-	"new":          New,
-	"none":         None,
-	"parent":       Parent,
-	"property":     Property,
-	"return":       Return,
-	"scriptname":   ScriptName,
-	"self":         Self,
-	"state":        State,
-	"string":       String,
-	"true":         True,
-	"while":        While,
-}
-
-var names = map[Type]string{
-	Illegal:        "Illegal",
-	EOF:            "EOF",
-	Add:            "Add",
-	As:             "As",
-	Assign:         "Assign",
-	AssignAdd:      "AssignAdd",
-	AssignDivide:   "AssignDivide",
-	AssignModulo:   "AssignModulo",
-	AssignMultiply: "AssignMultiply",
-	AssignSubtract: "AssignSubtract",
-	Auto:           "Auto",
-	AutoReadOnly:   "AutoReadOnly",
-	BlockComment:   "BlockComment",
-	Bool:           "Bool",
-	Comma:          "Comma",
-	Conditional:    "Conditional",
-	Divide:         "Divide",
-	DocComment:     "DocComment",
-	Dot:            "Dot",
-	Else:           "Else",
-	ElseIf:         "ElseIf",
-	EndEvent:       "EndEvent",
-	EndFunction:    "EndFunction",
-	EndIf:          "EndIf",
-	EndProperty:    "EndProperty",
-	EndState:       "EndState",
-	EndWhile:       "EndWhile",
-	Equal:          "Equal",
-	Event:          "Event",
-	Extends:        "Extends",
-	False:          "False",
-	Float:          "Float",
-	FloatLiteral:   "FloatLiteral",
-	Function:       "Function",
-	Global:         "Global",
-	Greater:        "Greater",
-	GreaterOrEqual: "GreaterOrEqual",
-	Hidden:         "Hidden",
-	Identifier:     "Identifier",
-	If:             "If",
-	Import:         "Import",
-	Int:            "Int",
-	IntLiteral:     "IntLiteral",
-	LBracket:       "LBracket",
-	Length:         "Length",
-	Less:           "Less",
-	LessOrEqual:    "LessOrEqual",
-	LineComment:    "LineComment",
-	LogicalAnd:     "LogicalAnd",
-	LogicalNot:     "LogicalNot",
-	LogicalOr:      "LogicalOr",
-	LParen:         "LParen",
-	Modulo:         "Modulo",
-	Multiply:       "Multiply",
-	Native:         "Native",
-	New:            "New",
-	Newline:        "Newline",
-	None:           "None",
-	NotEqual:       "NotEqual",
-	Parent:         "Parent",
-	Property:       "Property",
-	RBracket:       "RBracket",
-	Return:         "Return",
-	RParen:         "RParen",
-	ScriptName:     "ScriptName",
-	Self:           "Self",
-	State:          "State",
-	String:         "String",
-	StringLiteral:  "StringLiteral",
-	Subtract:       "Subtract",
-	True:           "True",
-	While:          "While",
-}
@@ -0,0 +1,56 @@
+package token
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTypesExhaustive(t *testing.T) {
+	seen := make(map[string]Type)
+	for typ := Type(0); int(typ) < typeCount; typ++ {
+		name := typ.String()
+		if name == "" || name == "<unknown>" {
+			t.Errorf("Type(%d) has no name", typ)
+			continue
+		}
+		if prev, ok := seen[name]; ok {
+			t.Errorf("Type(%d) and Type(%d) share the name %q", prev, typ, name)
+		}
+		seen[name] = typ
+		if typ.IsKeyword() && typ.Text() == "" {
+			t.Errorf("keyword %s has no text", typ)
+		}
+	}
+	if got := Type(typeCount).String(); got != "<unknown>" {
+		t.Errorf("Type(%d).String() = %q, want %q", typeCount, got, "<unknown>")
+	}
+}
+
+func TestLookupIdentifier(t *testing.T) {
+	keywordCount := 0
+	for typ := Type(0); int(typ) < typeCount; typ++ {
+		if !typ.IsKeyword() {
+			continue
+		}
+		keywordCount++
+		for _, text := range []string{typ.Text(), strings.ToLower(typ.Text()), strings.ToUpper(typ.Text())} {
+			if got := LookupIdentifier(text); got != typ {
+				t.Errorf("LookupIdentifier(%q) = %s, want %s", text, got, typ)
+			}
+		}
+	}
+	endCount := 0
+	for _, t := range keywordEnds {
+		if t != Illegal {
+			endCount++
+		}
+	}
+	if keywordCount != endCount {
+		t.Errorf("found %d keyword types, but %d keywords in the trie", keywordCount, endCount)
+	}
+	for _, text := range []string{"", "Foo", "Aut", "Autos", "Auto_", "Auto1", "[uto", "`uto", "@uto", "Ãuto", "EndWhil", "EndWhileX"} {
+		if got := LookupIdentifier(text); got != Identifier {
+			t.Errorf("LookupIdentifier(%q) = %s, want %s", text, got, Identifier)
+		}
+	}
+}
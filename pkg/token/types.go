@@ -0,0 +1,478 @@
+// Code generated by internal/gen; DO NOT EDIT.
+
+package token
+
+// The set of tokens for Papyrus.
+const (
+	Illegal Type = iota
+	EOF
+	Add
+	As
+	Assign
+	AssignAdd
+	AssignDivide
+	AssignModulo
+	AssignMultiply
+	AssignSubtract
+	Auto
+	AutoReadOnly
+	BlockComment
+	Bool
+	Comma
+	Conditional
+	Divide
+	DocComment
+	Dot
+	Else
+	ElseIf
+	EndEvent
+	EndFunction
+	EndIf
+	EndProperty
+	EndState
+	EndWhile
+	Equal
+	Event
+	Extends
+	False
+	Float
+	FloatLiteral
+	Function
+	Global
+	Greater
+	GreaterOrEqual
+	Hidden
+	Identifier
+	If
+	Import
+	Int
+	IntLiteral
+	LBracket
+	Length
+	Less
+	LessOrEqual
+	LineComment
+	LogicalAnd
+	LogicalNot
+	LogicalOr
+	LParen
+	Modulo
+	Multiply
+	Native
+	New
+	Newline
+	None
+	NotEqual
+	Parent
+	Property
+	RBracket
+	Return
+	RParen
+	ScriptName
+	Self
+	State
+	String
+	StringLiteral
+	Subtract
+	True
+	While
+
+	// typeCount is the number of token types.
+	typeCount int = iota
+)
+
+var names = [typeCount]string{
+	Illegal:        "Illegal",
+	EOF:            "EOF",
+	Add:            "Add",
+	As:             "As",
+	Assign:         "Assign",
+	AssignAdd:      "AssignAdd",
+	AssignDivide:   "AssignDivide",
+	AssignModulo:   "AssignModulo",
+	AssignMultiply: "AssignMultiply",
+	AssignSubtract: "AssignSubtract",
+	Auto:           "Auto",
+	AutoReadOnly:   "AutoReadOnly",
+	BlockComment:   "BlockComment",
+	Bool:           "Bool",
+	Comma:          "Comma",
+	Conditional:    "Conditional",
+	Divide:         "Divide",
+	DocComment:     "DocComment",
+	Dot:            "Dot",
+	Else:           "Else",
+	ElseIf:         "ElseIf",
+	EndEvent:       "EndEvent",
+	EndFunction:    "EndFunction",
+	EndIf:          "EndIf",
+	EndProperty:    "EndProperty",
+	EndState:       "EndState",
+	EndWhile:       "EndWhile",
+	Equal:          "Equal",
+	Event:          "Event",
+	Extends:        "Extends",
+	False:          "False",
+	Float:          "Float",
+	FloatLiteral:   "FloatLiteral",
+	Function:       "Function",
+	Global:         "Global",
+	Greater:        "Greater",
+	GreaterOrEqual: "GreaterOrEqual",
+	Hidden:         "Hidden",
+	Identifier:     "Identifier",
+	If:             "If",
+	Import:         "Import",
+	Int:            "Int",
+	IntLiteral:     "IntLiteral",
+	LBracket:       "LBracket",
+	Length:         "Length",
+	Less:           "Less",
+	LessOrEqual:    "LessOrEqual",
+	LineComment:    "LineComment",
+	LogicalAnd:     "LogicalAnd",
+	LogicalNot:     "LogicalNot",
+	LogicalOr:      "LogicalOr",
+	LParen:         "LParen",
+	Modulo:         "Modulo",
+	Multiply:       "Multiply",
+	Native:         "Native",
+	New:            "New",
+	Newline:        "Newline",
+	None:           "None",
+	NotEqual:       "NotEqual",
+	Parent:         "Parent",
+	Property:       "Property",
+	RBracket:       "RBracket",
+	Return:         "Return",
+	RParen:         "RParen",
+	ScriptName:     "ScriptName",
+	Self:           "Self",
+	State:          "State",
+	String:         "String",
+	StringLiteral:  "StringLiteral",
+	Subtract:       "Subtract",
+	True:           "True",
+	While:          "While",
+}
+
+var texts = [typeCount]string{
+	Add:            "+",
+	As:             "As",
+	Assign:         "=",
+	AssignAdd:      "+=",
+	AssignDivide:   "/=",
+	AssignModulo:   "%=",
+	AssignMultiply: "*=",
+	AssignSubtract: "-=",
+	Auto:           "Auto",
+	AutoReadOnly:   "AutoReadOnly",
+	Bool:           "Bool",
+	Comma:          ",",
+	Conditional:    "Conditional",
+	Divide:         "/",
+	Dot:            ".",
+	Else:           "Else",
+	ElseIf:         "ElseIf",
+	EndEvent:       "EndEvent",
+	EndFunction:    "EndFunction",
+	EndIf:          "EndIf",
+	EndProperty:    "EndProperty",
+	EndState:       "EndState",
+	EndWhile:       "EndWhile",
+	Equal:          "==",
+	Event:          "Event",
+	Extends:        "Extends",
+	False:          "False",
+	Float:          "Float",
+	Function:       "Function",
+	Global:         "Global",
+	Greater:        ">",
+	GreaterOrEqual: ">=",
+	Hidden:         "Hidden",
+	If:             "If",
+	Import:         "Import",
+	Int:            "Int",
+	LBracket:       "[",
+	Length:         "Length",
+	Less:           "<",
+	LessOrEqual:    "<=",
+	LogicalAnd:     "&&",
+	LogicalNot:     "!",
+	LogicalOr:      "||",
+	LParen:         "(",
+	Modulo:         "%",
+	Multiply:       "*",
+	Native:         "Native",
+	New:            "New",
+	Newline:        "\n",
+	None:           "None",
+	NotEqual:       "!=",
+	Parent:         "Parent",
+	Property:       "Property",
+	RBracket:       "]",
+	Return:         "Return",
+	RParen:         ")",
+	ScriptName:     "ScriptName",
+	Self:           "Self",
+	State:          "State",
+	String:         "String",
+	Subtract:       "-",
+	True:           "True",
+	While:          "While",
+}
+
+// keywordTrie is a trie over the lowercase letters of every keyword. Each
+// node holds the index of its child node for each letter or zero if there is
+// none (the root is never a child).
+var keywordTrie = [183][26]uint8{
+	0:   {'a' - 'a': 1, 'b' - 'a': 14, 'c' - 'a': 18, 'e' - 'a': 29, 'f' - 'a': 80, 'g' - 'a': 96, 'h' - 'a': 102, 'i' - 'a': 108, 'l' - 'a': 117, 'n' - 'a': 123, 'p' - 'a': 134, 'r' - 'a': 147, 's' - 'a': 153, 't' - 'a': 174, 'w' - 'a': 178},
+	1:   {'s' - 'a': 2, 'u' - 'a': 3},
+	3:   {'t' - 'a': 4},
+	4:   {'o' - 'a': 5},
+	5:   {'r' - 'a': 6},
+	6:   {'e' - 'a': 7},
+	7:   {'a' - 'a': 8},
+	8:   {'d' - 'a': 9},
+	9:   {'o' - 'a': 10},
+	10:  {'n' - 'a': 11},
+	11:  {'l' - 'a': 12},
+	12:  {'y' - 'a': 13},
+	14:  {'o' - 'a': 15},
+	15:  {'o' - 'a': 16},
+	16:  {'l' - 'a': 17},
+	18:  {'o' - 'a': 19},
+	19:  {'n' - 'a': 20},
+	20:  {'d' - 'a': 21},
+	21:  {'i' - 'a': 22},
+	22:  {'t' - 'a': 23},
+	23:  {'i' - 'a': 24},
+	24:  {'o' - 'a': 25},
+	25:  {'n' - 'a': 26},
+	26:  {'a' - 'a': 27},
+	27:  {'l' - 'a': 28},
+	29:  {'l' - 'a': 30, 'n' - 'a': 35, 'v' - 'a': 70, 'x' - 'a': 74},
+	30:  {'s' - 'a': 31},
+	31:  {'e' - 'a': 32},
+	32:  {'i' - 'a': 33},
+	33:  {'f' - 'a': 34},
+	35:  {'d' - 'a': 36},
+	36:  {'e' - 'a': 37, 'f' - 'a': 42, 'i' - 'a': 50, 'p' - 'a': 52, 's' - 'a': 60, 'w' - 'a': 65},
+	37:  {'v' - 'a': 38},
+	38:  {'e' - 'a': 39},
+	39:  {'n' - 'a': 40},
+	40:  {'t' - 'a': 41},
+	42:  {'u' - 'a': 43},
+	43:  {'n' - 'a': 44},
+	44:  {'c' - 'a': 45},
+	45:  {'t' - 'a': 46},
+	46:  {'i' - 'a': 47},
+	47:  {'o' - 'a': 48},
+	48:  {'n' - 'a': 49},
+	50:  {'f' - 'a': 51},
+	52:  {'r' - 'a': 53},
+	53:  {'o' - 'a': 54},
+	54:  {'p' - 'a': 55},
+	55:  {'e' - 'a': 56},
+	56:  {'r' - 'a': 57},
+	57:  {'t' - 'a': 58},
+	58:  {'y' - 'a': 59},
+	60:  {'t' - 'a': 61},
+	61:  {'a' - 'a': 62},
+	62:  {'t' - 'a': 63},
+	63:  {'e' - 'a': 64},
+	65:  {'h' - 'a': 66},
+	66:  {'i' - 'a': 67},
+	67:  {'l' - 'a': 68},
+	68:  {'e' - 'a': 69},
+	70:  {'e' - 'a': 71},
+	71:  {'n' - 'a': 72},
+	72:  {'t' - 'a': 73},
+	74:  {'t' - 'a': 75},
+	75:  {'e' - 'a': 76},
+	76:  {'n' - 'a': 77},
+	77:  {'d' - 'a': 78},
+	78:  {'s' - 'a': 79},
+	80:  {'a' - 'a': 81, 'l' - 'a': 85, 'u' - 'a': 89},
+	81:  {'l' - 'a': 82},
+	82:  {'s' - 'a': 83},
+	83:  {'e' - 'a': 84},
+	85:  {'o' - 'a': 86},
+	86:  {'a' - 'a': 87},
+	87:  {'t' - 'a': 88},
+	89:  {'n' - 'a': 90},
+	90:  {'c' - 'a': 91},
+	91:  {'t' - 'a': 92},
+	92:  {'i' - 'a': 93},
+	93:  {'o' - 'a': 94},
+	94:  {'n' - 'a': 95},
+	96:  {'l' - 'a': 97},
+	97:  {'o' - 'a': 98},
+	98:  {'b' - 'a': 99},
+	99:  {'a' - 'a': 100},
+	100: {'l' - 'a': 101},
+	102: {'i' - 'a': 103},
+	103: {'d' - 'a': 104},
+	104: {'d' - 'a': 105},
+	105: {'e' - 'a': 106},
+	106: {'n' - 'a': 107},
+	108: {'f' - 'a': 109, 'm' - 'a': 110, 'n' - 'a': 115},
+	110: {'p' - 'a': 111},
+	111: {'o' - 'a': 112},
+	112: {'r' - 'a': 113},
+	113: {'t' - 'a': 114},
+	115: {'t' - 'a': 116},
+	117: {'e' - 'a': 118},
+	118: {'n' - 'a': 119},
+	119: {'g' - 'a': 120},
+	120: {'t' - 'a': 121},
+	121: {'h' - 'a': 122},
+	123: {'a' - 'a': 124, 'e' - 'a': 129, 'o' - 'a': 131},
+	124: {'t' - 'a': 125},
+	125: {'i' - 'a': 126},
+	126: {'v' - 'a': 127},
+	127: {'e' - 'a': 128},
+	129: {'w' - 'a': 130},
+	131: {'n' - 'a': 132},
+	132: {'e' - 'a': 133},
+	134: {'a' - 'a': 135, 'r' - 'a': 140},
+	135: {'r' - 'a': 136},
+	136: {'e' - 'a': 137},
+	137: {'n' - 'a': 138},
+	138: {'t' - 'a': 139},
+	140: {'o' - 'a': 141},
+	141: {'p' - 'a': 142},
+	142: {'e' - 'a': 143},
+	143: {'r' - 'a': 144},
+	144: {'t' - 'a': 145},
+	145: {'y' - 'a': 146},
+	147: {'e' - 'a': 148},
+	148: {'t' - 'a': 149},
+	149: {'u' - 'a': 150},
+	150: {'r' - 'a': 151},
+	151: {'n' - 'a': 152},
+	153: {'c' - 'a': 154, 'e' - 'a': 163, 't' - 'a': 166},
+	154: {'r' - 'a': 155},
+	155: {'i' - 'a': 156},
+	156: {'p' - 'a': 157},
+	157: {'t' - 'a': 158},
+	158: {'n' - 'a': 159},
+	159: {'a' - 'a': 160},
+	160: {'m' - 'a': 161},
+	161: {'e' - 'a': 162},
+	163: {'l' - 'a': 164},
+	164: {'f' - 'a': 165},
+	166: {'a' - 'a': 167, 'r' - 'a': 170},
+	167: {'t' - 'a': 168},
+	168: {'e' - 'a': 169},
+	170: {'i' - 'a': 171},
+	171: {'n' - 'a': 172},
+	172: {'g' - 'a': 173},
+	174: {'r' - 'a': 175},
+	175: {'u' - 'a': 176},
+	176: {'e' - 'a': 177},
+	178: {'h' - 'a': 179},
+	179: {'i' - 'a': 180},
+	180: {'l' - 'a': 181},
+	181: {'e' - 'a': 182},
+}
+
+// keywordEnds holds the keyword that ends at each node in keywordTrie or
+// Illegal if none does.
+var keywordEnds = [183]Type{
+	2:   As,
+	5:   Auto,
+	13:  AutoReadOnly,
+	17:  Bool,
+	28:  Conditional,
+	32:  Else,
+	34:  ElseIf,
+	41:  EndEvent,
+	49:  EndFunction,
+	51:  EndIf,
+	59:  EndProperty,
+	64:  EndState,
+	69:  EndWhile,
+	73:  Event,
+	79:  Extends,
+	84:  False,
+	88:  Float,
+	95:  Function,
+	101: Global,
+	107: Hidden,
+	109: If,
+	114: Import,
+	116: Int,
+	122: Length,
+	128: Native,
+	130: New,
+	133: None,
+	139: Parent,
+	146: Property,
+	152: Return,
+	162: ScriptName,
+	165: Self,
+	169: State,
+	173: String,
+	177: True,
+	182: While,
+}
+
+var isKeyword = [typeCount]bool{
+	As:           true,
+	Auto:         true,
+	AutoReadOnly: true,
+	Bool:         true,
+	Conditional:  true,
+	Else:         true,
+	ElseIf:       true,
+	EndEvent:     true,
+	EndFunction:  true,
+	EndIf:        true,
+	EndProperty:  true,
+	EndState:     true,
+	EndWhile:     true,
+	Event:        true,
+	Extends:      true,
+	False:        true,
+	Float:        true,
+	Function:     true,
+	Global:       true,
+	Hidden:       true,
+	If:           true,
+	Import:       true,
+	Int:          true,
+	Length:       true,
+	Native:       true,
+	New:          true,
+	None:         true,
+	Parent:       true,
+	Property:     true,
+	Return:       true,
+	ScriptName:   true,
+	Self:         true,
+	State:        true,
+	String:       true,
+	True:         true,
+	While:        true,
+}
+
+var precedences = [typeCount]int{
+	Add:            4,
+	As:             6,
+	Divide:         5,
+	Dot:            7,
+	Equal:          3,
+	Greater:        3,
+	GreaterOrEqual: 3,
+	LBracket:       7,
+	Less:           3,
+	LessOrEqual:    3,
+	LogicalAnd:     2,
+	LogicalOr:      1,
+	LParen:         7,
+	Modulo:         5,
+	Multiply:       5,
+	NotEqual:       3,
+	Subtract:       4,
+}